@@ -9,6 +9,8 @@ import (
 	"os"
 	"strings"
 
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
 	"github.com/sourcegraph/src-cli/internal/api"
 	"github.com/sourcegraph/src-cli/internal/cmderrors"
 
@@ -34,6 +36,14 @@ Examples:
 
     	$ echo '<query>' | src api 'var1=val1' 'var2=val2'
 
+  Read the query and variables from files:
+
+    	$ src api -query=@query.graphql -vars=@vars.json
+
+  Print the whole response, including the "data" and "errors" envelope:
+
+    	$ src api -raw -query='query { currentUser { username } }'
+
   Searching for "Router" and getting result count:
 
     	$ echo 'query($query: String!) { search(query: $query) { results { resultCount } } }' | src api 'query=Router'
@@ -41,6 +51,13 @@ Examples:
   Get the curl command for a query (just add '-get-curl' in the flags section):
 
     	$ src api -get-curl -query='query { currentUser { username } }'
+
+The -query and -vars values are read from a file if they start with @. Use @@
+for a value starting with a literal @. The = is required, so that the value is
+not expanded as an argument file (see 'src help').
+
+Without -raw, the value of the response's "data" field is printed, and GraphQL
+errors are reported with exit code 2.
 `
 
 	flagSet := flag.NewFlagSet("api", flag.ExitOnError)
@@ -50,22 +67,30 @@ Examples:
 		fmt.Println(usage)
 	}
 	var (
-		queryFlag = flagSet.String("query", "", "GraphQL query to execute, e.g. 'query { currentUser { username } }' (stdin otherwise)")
-		varsFlag  = flagSet.String("vars", "", `GraphQL query variables to include as JSON string, e.g. '{"var": "val", "var2": "val2"}'`)
-		apiFlags  = api.NewFlags(flagSet)
+		queryFlag      = flagSet.String("query", "", "GraphQL query to execute, e.g. 'query { currentUser { username } }', or @file to read it from a file (stdin otherwise)")
+		queryStdinFlag = flagSet.Bool("query-stdin", false, "Read the GraphQL query from stdin, even if stdin is a terminal.")
+		varsFlag       = flagSet.String("vars", "", `GraphQL query variables to include as JSON string, e.g. '{"var": "val", "var2": "val2"}', or @file to read them from a file`)
+		rawFlag        = flagSet.Bool("raw", false, `Print the whole response instead of unwrapping its "data" field.`)
+		apiFlags       = api.NewFlags(flagSet)
 	)
 
 	handler := func(args []string) error {
-		err := flagSet.Parse(args)
-		if err != nil {
+		if err := flagSet.Parse(args); err != nil {
 			return err
 		}
 
+		if *queryFlag != "" && *queryStdinFlag {
+			return cmderrors.Usage("-query and -query-stdin cannot be combined")
+		}
+
 		// Build the GraphQL request.
-		query := *queryFlag
+		query, err := readAPIFlagValue(*queryFlag)
+		if err != nil {
+			return err
+		}
 		if query == "" {
 			// Read query from stdin instead.
-			if isatty.IsTerminal(os.Stdin.Fd()) {
+			if !*queryStdinFlag && isatty.IsTerminal(os.Stdin.Fd()) {
 				return cmderrors.Usage("expected query to be piped into 'src api' or -query flag to be specified")
 			}
 			data, err := io.ReadAll(os.Stdin)
@@ -77,9 +102,13 @@ Examples:
 
 		// Determine which variables to use in the request.
 		vars := map[string]interface{}{}
-		if *varsFlag != "" {
-			if err := json.Unmarshal([]byte(*varsFlag), &vars); err != nil {
-				return err
+		varsJSON, err := readAPIFlagValue(*varsFlag)
+		if err != nil {
+			return err
+		}
+		if varsJSON != "" {
+			if err := json.Unmarshal([]byte(varsJSON), &vars); err != nil {
+				return errors.Wrap(err, "parsing -vars")
 			}
 		}
 		for _, arg := range flagSet.Args() {
@@ -94,8 +123,18 @@ Examples:
 
 		// Perform the request.
 		var result interface{}
-		if ok, err := cfg.apiClient(apiFlags, flagSet.Output()).NewRequest(query, vars).DoRaw(context.Background(), &result); err != nil || !ok {
+		request := cfg.apiClient(apiFlags, flagSet.Output()).NewRequest(query, vars)
+		if *rawFlag {
+			if ok, err := request.DoRaw(context.Background(), &result); err != nil || !ok {
+				return err
+			}
+		} else if ok, err := request.Do(context.Background(), &result); err != nil {
+			if _, isGraphQL := err.(api.GraphQlErrors); isGraphQL {
+				return cmderrors.ExitCode(2, err)
+			}
 			return err
+		} else if !ok {
+			return nil
 		}
 
 		// Print the formatted JSON.
@@ -114,3 +153,21 @@ Examples:
 		usageFunc: usageFunc,
 	})
 }
+
+// readAPIFlagValue returns the contents of the file named by a flag value of the
+// form @file. A value starting with @@ is returned with the first @ removed, and
+// any other value is returned as is.
+func readAPIFlagValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "@@"):
+		return value[1:], nil
+	case strings.HasPrefix(value, "@"):
+		data, err := os.ReadFile(value[1:])
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	default:
+		return value, nil
+	}
+}
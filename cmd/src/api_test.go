@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadAPIFlagValue(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "query.graphql")
+	if err := os.WriteFile(file, []byte("query { currentUser { username } }\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for value, want := range map[string]string{
+		"":                  "",
+		`{"var": "val"}`:    `{"var": "val"}`,
+		"@" + file:          "query { currentUser { username } }\n",
+		"@@not-a-file.json": "@not-a-file.json",
+	} {
+		got, err := readAPIFlagValue(value)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", value, err)
+		}
+		if got != want {
+			t.Errorf("%q: got %q, want %q", value, got, want)
+		}
+	}
+
+	if _, err := readAPIFlagValue("@" + filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// srcFlagsEnv is the environment variable holding default flags that are parsed
+// before the flags given on the command line.
+const srcFlagsEnv = "SRC_FLAGS"
+
+// expandArgsFiles replaces each argument of the form @file, where file exists,
+// with the arguments read from the file, split as by splitArgs. Other arguments
+// starting with @, such as search queries for '@types/react', are passed on
+// unchanged. An argument starting with @@ is passed on with the first @ removed.
+func expandArgsFiles(args []string) ([]string, error) {
+	var expanded []string
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "@@"):
+			expanded = append(expanded, arg[1:])
+		case strings.HasPrefix(arg, "@") && isRegularFile(arg[1:]):
+			data, err := os.ReadFile(arg[1:])
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading arguments from %s", arg)
+			}
+			fileArgs, err := splitArgs(string(data))
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading arguments from %s", arg)
+			}
+			expanded = append(expanded, fileArgs...)
+		default:
+			expanded = append(expanded, arg)
+		}
+	}
+	return expanded, nil
+}
+
+func isRegularFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// srcFlags returns the flags in SRC_FLAGS that are defined by the flag set.
+// They are meant to be parsed before the command line, so that flags given on
+// the command line take precedence. Flags the flag set does not define are
+// ignored, so that SRC_FLAGS can hold flags for several commands.
+func srcFlags(flagSet *flag.FlagSet) ([]string, error) {
+	args, err := splitArgs(os.Getenv(srcFlagsEnv))
+	if err != nil {
+		return nil, errors.Wrap(err, srcFlagsEnv)
+	}
+
+	var flags []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" || arg == "--" {
+			return nil, errors.Newf("%s: expected only flags, got %q", srcFlagsEnv, arg)
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+
+		// A flag without a value may be followed by its value, unless it is a
+		// boolean flag.
+		takesValue := !hasValue && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-")
+		f := flagSet.Lookup(name)
+		if f != nil {
+			if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && bf.IsBoolFlag() {
+				takesValue = false
+			}
+		}
+
+		if f != nil {
+			flags = append(flags, arg)
+			if takesValue {
+				flags = append(flags, args[i+1])
+			}
+		}
+		if takesValue {
+			i++
+		}
+	}
+	return flags, nil
+}
+
+// splitArgs splits s into arguments at whitespace, as a shell would. Single and
+// double quotes group words, a backslash escapes the next character outside
+// single quotes, and a # at the start of an argument begins a comment that runs
+// to the end of the line.
+func splitArgs(s string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+		comment bool
+	)
+	for _, c := range s {
+		switch {
+		case comment:
+			if c == '\n' {
+				comment = false
+			}
+		case escaped:
+			current.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				current.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		case c == '#' && !inArg:
+			comment = true
+		default:
+			current.WriteRune(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.Newf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
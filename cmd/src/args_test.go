@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitArgs(t *testing.T) {
+	for input, want := range map[string][]string{
+		"":                               nil,
+		"  -v   -trace\n":                {"-v", "-trace"},
+		`-header "X-Team: search" -v`:    {"-header", "X-Team: search", "-v"},
+		`-query='a "b"' c\ d`:            {"-query=a \"b\"", "c d"},
+		"# comment\n-v # trailing\n-x#y": {"-v", "-x#y"},
+	} {
+		got, err := splitArgs(input)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", input, err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%q: unexpected args (-want +got):\n%s", input, diff)
+		}
+	}
+
+	for _, input := range []string{`"unterminated`, `trailing\`} {
+		if _, err := splitArgs(input); err == nil {
+			t.Errorf("%q: expected error", input)
+		}
+	}
+}
+
+func TestExpandArgsFiles(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "args")
+	if err := os.WriteFile(file, []byte("# CI defaults\n-insecure-skip-verify\n-header 'X-Team: search'\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := expandArgsFiles([]string{"search", "@" + file, "@@handle", "-v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"search", "-insecure-skip-verify", "-header", "X-Team: search", "@handle", "-v"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected args (-want +got):\n%s", diff)
+	}
+
+	// Arguments that don't name a file, such as search queries, are unchanged.
+	got, err = expandArgsFiles([]string{"search", "@types/react", "@" + file + ".missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"search", "@types/react", "@" + file + ".missing"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected args (-want +got):\n%s", diff)
+	}
+}
+
+func TestSrcFlags(t *testing.T) {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	trace := flagSet.Bool("trace", false, "")
+	first := flagSet.Int("first", 10, "")
+
+	t.Setenv(srcFlagsEnv, "-trace -unknown value -other -first 5 -unknown-bool")
+	defaults, err := srcFlags(flagSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"-trace", "-first", "5"}, defaults); diff != "" {
+		t.Errorf("unexpected defaults (-want +got):\n%s", diff)
+	}
+
+	// Flags on the command line override the defaults.
+	if err := flagSet.Parse(append(defaults, "-first=20", "arg")); err != nil {
+		t.Fatal(err)
+	}
+	if !*trace || *first != 20 || flagSet.Arg(0) != "arg" {
+		t.Errorf("unexpected flags: trace=%v first=%d args=%v", *trace, *first, flagSet.Args())
+	}
+
+	t.Setenv(srcFlagsEnv, "-trace positional")
+	if _, err := srcFlags(flagSet); err == nil {
+		t.Error("expected error for positional argument")
+	}
+}
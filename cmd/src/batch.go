@@ -18,6 +18,7 @@ The commands are:
 
 	apply                 applies a batch spec to create or update a batch
 	                      change
+	diff                  writes the combined diff of a batch spec's changesets
 	new                   creates a new batch spec YAML file
 	preview               creates a batch spec to be previewed or applied
 	remote                creates server side batch changes
@@ -45,7 +46,8 @@ Use "src batch [command] -h" for more information about a command.
 			"batch-changes",
 			"batches",
 		},
-		handler:   handler,
-		usageFunc: func() { fmt.Println(usage) },
+		handler:     handler,
+		subcommands: &batchCommands,
+		usageFunc:   func() { fmt.Println(usage) },
 	})
 }
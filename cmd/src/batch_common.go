@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/mattn/go-isatty"
+	"github.com/neelance/parallel"
 
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 	"github.com/sourcegraph/sourcegraph/lib/output"
@@ -82,6 +83,7 @@ type batchExecuteFlags struct {
 
 	apply         bool
 	cacheDir      string
+	remoteCache   string
 	tempDir       string
 	file          string
 	keepLogs      bool
@@ -91,6 +93,12 @@ type batchExecuteFlags struct {
 	cleanArchives bool
 	skipErrors    bool
 	runAsRoot     bool
+	resume        bool
+
+	skipImageCheck bool
+	secrets        stringSliceFlag
+	mounts         stringSliceFlag
+	allowRWMounts  bool
 
 	// EXPERIMENTAL
 	textOnly bool
@@ -121,6 +129,11 @@ func newBatchExecuteFlags(flagSet *flag.FlagSet, cacheDir, tempDir string) *batc
 		"Directory for caching results and repository archives.",
 	)
 
+	flagSet.StringVar(
+		&caf.remoteCache, "remote-cache", "",
+		"Share cached step results through object storage, e.g. s3://bucket/prefix or gs://bucket/prefix. Results are still cached locally in the -cache directory. S3 credentials and the region are found as by the AWS CLI, e.g. from the environment, AWS_PROFILE, web identity tokens or instance metadata; Google Cloud Storage uses Application Default Credentials.",
+	)
+
 	flagSet.StringVar(
 		&caf.tempDir, "tmp", tempDir,
 		"Directory for storing temporary data, such as log files. Default is /tmp. Can also be set with environment variable SRC_BATCH_TMP_DIR; if both are set, this flag will be used and not the environment variable.",
@@ -148,12 +161,37 @@ func newBatchExecuteFlags(flagSet *flag.FlagSet, cacheDir, tempDir string) *batc
 
 	flagSet.BoolVar(
 		&caf.skipErrors, "skip-errors", false,
-		"If true, errors encountered while executing steps in a repository won't stop the execution of the batch spec but only cause that repository to be skipped.",
+		"If true, errors encountered while executing steps in a repository won't stop the execution of the batch spec but only cause that repository to be skipped. The failed repositories are reported at the end, and src exits with a non-zero status.",
+	)
+
+	flagSet.BoolVar(
+		&caf.resume, "resume", false,
+		"If true, resumes an interrupted execution of the same batch spec, only executing the tasks that did not complete. Requires -cache.",
+	)
+
+	flagSet.BoolVar(
+		&caf.skipImageCheck, "skip-image-check", false,
+		"If true, skips checking that the container images of all steps exist before pulling any of them.",
+	)
+
+	flagSet.Var(
+		&caf.secrets, "secret",
+		"The name of an environment variable to expose to every step container as a secret. Its value is redacted from step output and logs, is not part of the cache key, and must not be written to the repository. Can be repeated.",
+	)
+
+	flagSet.Var(
+		&caf.mounts, "mount",
+		`A local file or directory to mount into every step container, as "host-path:container-path", optionally followed by ":ro" (the default) or ":rw". Like secrets, the contents are not part of the cache key. Can be repeated.`,
+	)
+
+	flagSet.BoolVar(
+		&caf.allowRWMounts, "allow-rw-mounts", false,
+		"If true, allows -mount to mount paths read-write. Steps could then change files outside the workspace, which makes runs harder to reproduce.",
 	)
 
 	flagSet.StringVar(
 		&caf.workspace, "workspace", "auto",
-		`Workspace mode to use ("auto", "bind", or "volume")`,
+		`Workspace mode to use ("auto", "bind", or "volume"). "auto" uses "volume" when the Docker daemon is remote, e.g. when DOCKER_HOST is set to a tcp:// or ssh:// host.`,
 	)
 
 	flagSet.BoolVar(verbose, "v", false, "print verbose output")
@@ -252,6 +290,10 @@ type executeBatchSpecOpts struct {
 	applyBatchSpec bool
 	file           string
 
+	// writeDiffs, if set, is called with the changeset specs built by the
+	// execution instead of uploading them and creating a batch spec.
+	writeDiffs func(repos []*graphql.Repository, specs []*batcheslib.ChangesetSpec) error
+
 	client api.Client
 }
 
@@ -268,11 +310,20 @@ func createDockerWatchdog(ctx context.Context, execUI ui.ExecUI) *watchdog.Watch
 // Sourcegraph, including execution as needed and applying the resulting batch
 // spec if specified.
 func executeBatchSpec(ctx context.Context, opts executeBatchSpecOpts) (err error) {
+	if opts.flags.resume {
+		if opts.flags.clearCache {
+			return cmderrors.Usage("-resume and -clear-cache cannot be combined")
+		}
+		if opts.flags.cacheDir == "" {
+			return cmderrors.Usage("-resume requires -cache")
+		}
+	}
+
 	var execUI ui.ExecUI
 	if opts.flags.textOnly {
 		execUI = &ui.JSONLines{}
 	} else {
-		out := output.NewOutput(os.Stderr, output.OutputOpts{Verbose: *verbose})
+		out := output.NewOutput(statusWriter(os.Stderr), output.OutputOpts{Verbose: *verbose})
 		execUI = &ui.TUI{Out: out}
 	}
 
@@ -317,11 +368,35 @@ func executeBatchSpec(ctx context.Context, opts executeBatchSpecOpts) (err error
 		return err
 	}
 
+	secrets, err := batchSecrets(opts.flags.secrets)
+	if err != nil {
+		return err
+	}
+
+	mounts, err := batchMounts(opts.flags.mounts, opts.flags.allowRWMounts)
+	if err != nil {
+		return err
+	}
+
 	parallelism, err := getBatchParallelism(ctx, opts.flags.parallelism)
 	if err != nil {
 		return err
 	}
 
+	// With a remote Docker daemon, e.g. on CI with DOCKER_HOST set, local files
+	// can't be bind mounted into containers and are copied in instead.
+	remoteDocker, err := docker.IsRemoteDaemon(ctx)
+	if err != nil {
+		return err
+	}
+	if remoteDocker {
+		for _, m := range mounts {
+			if m.ReadWrite {
+				return errors.Newf("cannot mount %s read-write: the Docker daemon is remote, so mounts are copied into the step containers", m.Source)
+			}
+		}
+	}
+
 	// On Linux only, we also need to figure out if we need to override the
 	// temporary directory — Docker Desktop restricts file mounts to /home only
 	// by default.
@@ -371,6 +446,11 @@ func executeBatchSpec(ctx context.Context, opts executeBatchSpecOpts) (err error
 
 	if len(batchSpec.Steps) > 0 {
 		execUI.PreparingContainerImages()
+		if !opts.flags.skipImageCheck {
+			if err := checkBatchSpecImages(ctx, batchSpec.Steps); err != nil {
+				return err
+			}
+		}
 		images, err := svc.EnsureDockerImages(
 			ctx,
 			imageCache,
@@ -385,7 +465,7 @@ func executeBatchSpec(ctx context.Context, opts executeBatchSpecOpts) (err error
 
 		execUI.DeterminingWorkspaceCreatorType()
 		var typ workspace.CreatorType
-		workspaceCreator, typ = workspace.NewCreator(ctx, opts.flags.workspace, opts.flags.cacheDir, opts.flags.tempDir, images)
+		workspaceCreator, typ = workspace.NewCreator(ctx, opts.flags.workspace, opts.flags.cacheDir, opts.flags.tempDir, images, remoteDocker)
 		if typ == workspace.CreatorTypeVolume {
 			// This creator type requires an additional image, so let's ensure it exists.
 			_, err = imageCache.Ensure(ctx, workspace.DockerVolumeWorkspaceImage)
@@ -410,6 +490,31 @@ func executeBatchSpec(ctx context.Context, opts executeBatchSpecOpts) (err error
 		execUI.DeterminingWorkspacesSuccess(len(workspaces), len(repos), nil, nil)
 	}
 
+	execCache := executor.NewDiskCache(opts.flags.cacheDir)
+	if opts.flags.remoteCache != "" {
+		execCache, err = executor.NewRemoteCache(ctx, opts.flags.remoteCache, execCache)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The run state records the tasks that complete, so that the execution can
+	// be resumed with -resume if it is interrupted.
+	var runState *executor.RunState
+	if opts.flags.cacheDir != "" {
+		statePath := executor.RunStatePath(opts.flags.cacheDir, []byte(rawSpec))
+		if opts.flags.resume {
+			runState, err = executor.LoadRunState(statePath)
+			if os.IsNotExist(err) {
+				return errors.New("no interrupted execution of this batch spec to resume; run it without -resume, which still uses the cached step results")
+			} else if err != nil {
+				return err
+			}
+		} else {
+			runState = executor.NewRunState(statePath)
+		}
+	}
+
 	archiveRegistry := repozip.NewArchiveRegistry(opts.client, opts.flags.cacheDir, opts.flags.cleanArchives)
 	logManager := log.NewDiskManager(opts.flags.tempDir, opts.flags.keepLogs)
 	coord := executor.NewCoordinator(
@@ -425,12 +530,16 @@ func executeBatchSpec(ctx context.Context, opts executeBatchSpecOpts) (err error
 				TempDir:             opts.flags.tempDir,
 				GlobalEnv:           os.Environ(),
 				ForceRoot:           opts.flags.runAsRoot,
+				RemoteDocker:        remoteDocker,
+				Secrets:             secrets,
+				Mounts:              mounts,
 				BinaryDiffs:         ffs.BinaryDiffs,
 			},
 			Logger:      logManager,
-			Cache:       executor.NewDiskCache(opts.flags.cacheDir),
+			Cache:       execCache,
 			BinaryDiffs: ffs.BinaryDiffs,
 			GlobalEnv:   os.Environ(),
+			RunState:    runState,
 		},
 	)
 
@@ -460,6 +569,13 @@ func executeBatchSpec(ctx context.Context, opts executeBatchSpecOpts) (err error
 		}
 	}
 	execUI.CheckingCacheSuccess(len(specs), len(uncachedTasks))
+	if opts.flags.resume {
+		completed, err := coord.CompletedTasks(tasks)
+		if err != nil {
+			return err
+		}
+		execUI.ResumingExecution(completed, len(tasks))
+	}
 
 	taskExecUI := execUI.ExecutingTasks(*verbose, parallelism)
 	freshSpecs, logFiles, execErr := coord.ExecuteAndBuildSpecs(ctx, batchSpec, uncachedTasks, taskExecUI)
@@ -474,11 +590,21 @@ func executeBatchSpec(ctx context.Context, opts executeBatchSpecOpts) (err error
 	if err != nil && !opts.flags.skipErrors {
 		return err
 	}
+	// A complete execution leaves nothing to resume.
+	if execErr == nil && runState != nil {
+		if err := runState.Remove(); err != nil {
+			return errors.Wrap(err, "removing run state")
+		}
+	}
+	// skippedErr holds the errors skipped with -skip-errors, which are reported
+	// again once the batch spec has been created.
+	var skippedErr error
 	if err == nil || opts.flags.skipErrors {
 		if err == nil {
 			taskExecUI.Success()
 		} else {
 			execUI.ExecutingTasksSkippingErrors(err)
+			skippedErr = err
 		}
 	} else {
 		if err != nil {
@@ -499,6 +625,13 @@ func executeBatchSpec(ctx context.Context, opts executeBatchSpecOpts) (err error
 		return err
 	}
 
+	if opts.writeDiffs != nil {
+		if err := opts.writeDiffs(repos, specs); err != nil {
+			return err
+		}
+		return skippedErrorsExitCode(skippedErr)
+	}
+
 	ids := make([]graphql.ChangesetSpecID, len(specs))
 
 	if len(specs) > 0 {
@@ -545,7 +678,7 @@ func executeBatchSpec(ctx context.Context, opts executeBatchSpecOpts) (err error
 
 	if !opts.applyBatchSpec {
 		execUI.PreviewBatchSpec(previewURL)
-		return
+		return skippedErrorsExitCode(skippedErr)
 	}
 
 	execUI.ApplyingBatchSpec()
@@ -555,7 +688,130 @@ func executeBatchSpec(ctx context.Context, opts executeBatchSpecOpts) (err error
 	}
 	execUI.ApplyingBatchSpecSuccess(cfg.Endpoint + batch.URL)
 
-	return nil
+	return skippedErrorsExitCode(skippedErr)
+}
+
+// batchSecrets reads the values of the secrets given with -secret from the
+// environment.
+func batchSecrets(names []string) (map[string]string, error) {
+	secrets := map[string]string{}
+	for _, name := range names {
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			return nil, errors.Newf("secret %s is not set in the environment", name)
+		}
+		secrets[name] = value
+	}
+	return secrets, nil
+}
+
+// batchMounts parses the mounts given with -mount. The host paths must exist,
+// and are made absolute. Read-write mounts are only allowed if allowReadWrite is
+// true, and each container path can only be mounted once.
+func batchMounts(specs []string, allowReadWrite bool) ([]docker.Mount, error) {
+	var mounts []docker.Mount
+	targets := map[string]string{}
+	for _, spec := range specs {
+		parts := strings.Split(spec, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return nil, cmderrors.Usagef("invalid -mount %q: must be host-path:container-path[:ro|rw]", spec)
+		}
+		if !path.IsAbs(parts[1]) {
+			return nil, cmderrors.Usagef("invalid -mount %q: the container path must be absolute", spec)
+		}
+
+		if other, ok := targets[parts[1]]; ok {
+			return nil, cmderrors.Usagef("invalid -mount %q: %s is already mounted by -mount %q", spec, parts[1], other)
+		}
+		targets[parts[1]] = spec
+
+		m := docker.Mount{Target: parts[1]}
+		if len(parts) == 3 {
+			switch parts[2] {
+			case "ro":
+			case "rw":
+				if !allowReadWrite {
+					return nil, cmderrors.Usagef("invalid -mount %q: read-write mounts require -allow-rw-mounts", spec)
+				}
+				m.ReadWrite = true
+			default:
+				return nil, cmderrors.Usagef("invalid -mount %q: the mode must be ro or rw", spec)
+			}
+		}
+
+		source, err := filepath.Abs(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(source); err != nil {
+			if os.IsNotExist(err) {
+				return nil, errors.Newf("mount path %s does not exist", parts[0])
+			}
+			return nil, errors.Wrap(err, "mount path validation")
+		}
+		m.Source = source
+		mounts = append(mounts, m)
+	}
+	return mounts, nil
+}
+
+// checkBatchSpecImages checks that the container images of the steps exist, so
+// that a mistyped image fails the run before any images are pulled.
+func checkBatchSpecImages(ctx context.Context, steps []batcheslib.Step) error {
+	var names []string
+	seen := map[string]bool{}
+	for _, step := range steps {
+		if !seen[step.Container] {
+			seen[step.Container] = true
+			names = append(names, step.Container)
+		}
+	}
+
+	err := docker.CheckImages(ctx, names)
+	if errors.HasType(err, &docker.ImageCheckError{}) {
+		return errors.Newf("%s\n\nCheck the container images of the steps in the batch spec, and that you are logged in to their registries. Use -skip-image-check to skip this check.", err)
+	}
+	return err
+}
+
+// skippedErrorsExitCode returns the error to exit with once the batch spec has
+// been created despite the given errors, which were skipped with -skip-errors.
+// It is nil if no errors were skipped, and otherwise makes src exit non-zero,
+// so that failures are noticed in CI.
+func skippedErrorsExitCode(skipped error) error {
+	if skipped == nil {
+		return nil
+	}
+	repos := failedRepositories(skipped)
+	if len(repos) == 0 {
+		return cmderrors.ExitCode(1, errors.New("errors were skipped because -skip-errors was used"))
+	}
+	return cmderrors.ExitCode(1, errors.Newf(
+		"execution failed in %d repositories, which were skipped because -skip-errors was used: %s",
+		len(repos), strings.Join(repos, ", "),
+	))
+}
+
+// failedRepositories returns the names of the repositories that task execution
+// failed in, according to the given execution errors.
+func failedRepositories(err error) []string {
+	var repos []string
+	switch errs := err.(type) {
+	case parallel.Errors:
+		for _, e := range errs {
+			repos = append(repos, failedRepositories(e)...)
+		}
+	case errors.MultiError:
+		for _, e := range errs.Errors() {
+			repos = append(repos, failedRepositories(e)...)
+		}
+	default:
+		var taskErr executor.TaskExecutionErr
+		if errors.As(err, &taskErr) {
+			repos = append(repos, taskErr.Repository)
+		}
+	}
+	return repos
 }
 
 func setReadDeadlineOnCancel(ctx context.Context, f *os.File) {
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/neelance/parallel"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/src-cli/internal/batches/docker"
+	"github.com/sourcegraph/src-cli/internal/batches/executor"
+	"github.com/sourcegraph/src-cli/internal/cmderrors"
+)
+
+func TestSkippedErrorsExitCode(t *testing.T) {
+	if err := skippedErrorsExitCode(nil); err != nil {
+		t.Fatalf("unexpected error without skipped errors: %s", err)
+	}
+
+	skipped := errors.Append(
+		parallel.Errors{
+			executor.TaskExecutionErr{Err: errors.New("step 1 failed"), Repository: "github.com/a/a"},
+			executor.TaskExecutionErr{Err: errors.New("step 2 failed"), Repository: "github.com/b/b"},
+		},
+		errors.New("importing changesets failed"),
+	)
+	if diff := cmp.Diff([]string{"github.com/a/a", "github.com/b/b"}, failedRepositories(skipped)); diff != "" {
+		t.Errorf("unexpected failed repositories (-want +got):\n%s", diff)
+	}
+
+	err := skippedErrorsExitCode(skipped)
+	var exitErr *cmderrors.ExitCodeError
+	if !errors.As(err, &exitErr) || exitErr.Code() != 1 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestBatchMounts(t *testing.T) {
+	dir := t.TempDir()
+
+	mounts, err := batchMounts([]string{dir + ":/lint", dir + ":/rules:ro"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []docker.Mount{
+		{Source: dir, Target: "/lint"},
+		{Source: dir, Target: "/rules"},
+	}
+	if diff := cmp.Diff(want, mounts); diff != "" {
+		t.Errorf("unexpected mounts (-want +got):\n%s", diff)
+	}
+
+	mounts, err = batchMounts([]string{dir + ":/cache:rw"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]docker.Mount{{Source: dir, Target: "/cache", ReadWrite: true}}, mounts); diff != "" {
+		t.Errorf("unexpected mounts (-want +got):\n%s", diff)
+	}
+
+	for _, spec := range []string{
+		dir,
+		dir + ":lint",
+		dir + ":/lint:rx",
+		dir + ":/lint:rw",
+		filepath.Join(dir, "missing") + ":/lint",
+	} {
+		if _, err := batchMounts([]string{spec}, false); err == nil {
+			t.Errorf("batchMounts(%q): expected error", spec)
+		}
+	}
+
+	if _, err := batchMounts([]string{dir + ":/lint", dir + ":/lint:ro"}, false); err == nil {
+		t.Error("expected error mounting /lint twice")
+	}
+}
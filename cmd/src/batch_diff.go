@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	batcheslib "github.com/sourcegraph/sourcegraph/lib/batches"
+
+	"github.com/sourcegraph/src-cli/internal/batches/graphql"
+	"github.com/sourcegraph/src-cli/internal/cmderrors"
+)
+
+func init() {
+	usage := `
+'src batch diff' executes the steps in a batch spec and writes the combined
+diff of the resulting changesets, without uploading anything to Sourcegraph.
+
+Each changeset's diff is preceded by a header naming its repository and
+branch. Changesets that import existing changesets have no diff and are omitted.
+
+Usage:
+
+    src batch diff [command options] [-f FILE] [-o FILE | -per-repo-dir DIR]
+    src batch diff [command options] FILE
+
+Examples:
+
+    $ src batch diff -f batch.spec.yaml
+
+    $ src batch diff -f batch.spec.yaml -o changes.patch
+
+  Write one patch per changeset, named after its repository and branch:
+
+    $ src batch diff -f batch.spec.yaml -per-repo-dir patches
+
+`
+
+	flagSet := flag.NewFlagSet("diff", flag.ExitOnError)
+	flags := newBatchExecuteFlags(flagSet, batchDefaultCacheDir(), batchDefaultTempDirPrefix())
+	var (
+		outputFlag     = flagSet.String("o", "", "The file to write the combined diff to. Default is standard output.")
+		perRepoDirFlag = flagSet.String("per-repo-dir", "", "Write one patch file per changeset to this directory instead of a combined diff.")
+	)
+
+	handler := func(args []string) error {
+		if err := flagSet.Parse(args); err != nil {
+			return err
+		}
+		if *outputFlag != "" && *perRepoDirFlag != "" {
+			return cmderrors.Usage("-o and -per-repo-dir cannot be combined")
+		}
+
+		file, err := getBatchSpecFile(flagSet, &flags.file)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := contextCancelOnInterrupt(context.Background())
+		defer cancel()
+
+		writeDiffs := func(repos []*graphql.Repository, specs []*batcheslib.ChangesetSpec) error {
+			diffs := batchChangesetDiffs(repos, specs)
+			if *perRepoDirFlag != "" {
+				return writeBatchDiffsPerRepo(*perRepoDirFlag, diffs)
+			}
+			if *outputFlag == "" {
+				return writeBatchDiff(os.Stdout, diffs)
+			}
+			f, err := os.Create(*outputFlag)
+			if err != nil {
+				return err
+			}
+			if err := writeBatchDiff(f, diffs); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		}
+
+		if err = executeBatchSpec(ctx, executeBatchSpecOpts{
+			flags:      flags,
+			client:     cfg.apiClient(flags.api, flagSet.Output()),
+			file:       file,
+			writeDiffs: writeDiffs,
+		}); err != nil {
+			return cmderrors.ExitCode(1, nil)
+		}
+
+		return nil
+	}
+
+	batchCommands = append(batchCommands, &command{
+		flagSet: flagSet,
+		handler: handler,
+		usageFunc: func() {
+			fmt.Fprintf(flag.CommandLine.Output(), "Usage of 'src batch %s':\n", flagSet.Name())
+			flagSet.PrintDefaults()
+			fmt.Println(usage)
+		},
+	})
+}
+
+// batchChangesetDiff is the diff of a changeset spec built by executing a batch
+// spec.
+type batchChangesetDiff struct {
+	Repository string
+	Branch     string
+	Diff       []byte
+}
+
+// batchChangesetDiffs returns the diffs of the changeset specs that create new
+// changesets, with the names of their repositories looked up in repos.
+func batchChangesetDiffs(repos []*graphql.Repository, specs []*batcheslib.ChangesetSpec) []batchChangesetDiff {
+	names := make(map[string]string, len(repos))
+	for _, repo := range repos {
+		names[repo.ID] = repo.Name
+	}
+
+	var diffs []batchChangesetDiff
+	for _, spec := range specs {
+		if spec.IsImportingExisting() {
+			continue
+		}
+		name, ok := names[spec.BaseRepository]
+		if !ok {
+			name = spec.BaseRepository
+		}
+		var diff []byte
+		for _, commit := range spec.Commits {
+			diff = append(diff, commit.Diff...)
+		}
+		diffs = append(diffs, batchChangesetDiff{
+			Repository: name,
+			Branch:     strings.TrimPrefix(spec.HeadRef, "refs/heads/"),
+			Diff:       diff,
+		})
+	}
+	return diffs
+}
+
+// writeBatchDiff writes the diffs to w, each preceded by a header. git apply
+// ignores the header lines, so a single changeset's diff can still be applied.
+func writeBatchDiff(w io.Writer, diffs []batchChangesetDiff) error {
+	for _, d := range diffs {
+		if _, err := fmt.Fprintf(w, "# Repository: %s\n# Branch: %s\n", d.Repository, d.Branch); err != nil {
+			return err
+		}
+		if _, err := w.Write(d.Diff); err != nil {
+			return err
+		}
+		if len(d.Diff) > 0 && !bytes.HasSuffix(d.Diff, []byte("\n")) {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeBatchDiffsPerRepo writes each diff to its own patch file in dir, named
+// after the repository and branch.
+func writeBatchDiffsPerRepo(dir string, diffs []batchChangesetDiff) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, d := range diffs {
+		name := strings.ReplaceAll(d.Repository+"-"+d.Branch, "/", "-") + ".patch"
+		var buf bytes.Buffer
+		if err := writeBatchDiff(&buf, []batchChangesetDiff{d}); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), buf.Bytes(), 0644); err != nil {
+			return errors.Wrapf(err, "writing patch for %s", d.Repository)
+		}
+	}
+	return nil
+}
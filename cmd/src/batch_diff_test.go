@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	batcheslib "github.com/sourcegraph/sourcegraph/lib/batches"
+
+	"github.com/sourcegraph/src-cli/internal/batches/graphql"
+)
+
+func TestBatchDiff(t *testing.T) {
+	repos := []*graphql.Repository{
+		{ID: "repo-1", Name: "github.com/sourcegraph/src-cli"},
+		{ID: "repo-2", Name: "github.com/sourcegraph/sourcegraph"},
+	}
+	specs := []*batcheslib.ChangesetSpec{
+		{
+			BaseRepository: "repo-1",
+			HeadRef:        "refs/heads/batch/hello",
+			Commits: []batcheslib.GitCommitDescription{
+				{Diff: []byte("diff --git a/README.md b/README.md\n+hello\n")},
+			},
+		},
+		{
+			BaseRepository: "repo-2",
+			HeadRef:        "refs/heads/batch/hello",
+			Commits: []batcheslib.GitCommitDescription{
+				{Diff: []byte("diff --git a/a.go b/a.go\n+a")},
+				{Diff: []byte("\ndiff --git a/b.go b/b.go\n+b\n")},
+			},
+		},
+	}
+
+	diffs := batchChangesetDiffs(repos, specs)
+
+	t.Run("combined", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeBatchDiff(&buf, diffs); err != nil {
+			t.Fatal(err)
+		}
+		want := `# Repository: github.com/sourcegraph/src-cli
+# Branch: batch/hello
+diff --git a/README.md b/README.md
++hello
+# Repository: github.com/sourcegraph/sourcegraph
+# Branch: batch/hello
+diff --git a/a.go b/a.go
++a
+diff --git a/b.go b/b.go
++b
+`
+		if diff := cmp.Diff(want, buf.String()); diff != "" {
+			t.Errorf("unexpected diff (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("per repo", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "patches")
+		if err := writeBatchDiffsPerRepo(dir, diffs); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "github.com-sourcegraph-src-cli-batch-hello.patch"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "# Repository: github.com/sourcegraph/src-cli\n# Branch: batch/hello\ndiff --git a/README.md b/README.md\n+hello\n"
+		if diff := cmp.Diff(want, string(data)); diff != "" {
+			t.Errorf("unexpected patch (-want +got):\n%s", diff)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "github.com-sourcegraph-sourcegraph-batch-hello.patch")); err != nil {
+			t.Error(err)
+		}
+	})
+}
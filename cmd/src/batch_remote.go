@@ -61,7 +61,7 @@ Examples:
 			return err
 		}
 
-		out := output.NewOutput(flagSet.Output(), output.OutputOpts{Verbose: *verbose})
+		out := output.NewOutput(statusWriter(flagSet.Output()), output.OutputOpts{Verbose: *verbose})
 		ui := &ui.TUI{Out: out}
 
 		// OK, now for the real stuff. We have to load in the batch spec, and we
@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"sort"
 
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 	"github.com/sourcegraph/sourcegraph/lib/output"
@@ -30,12 +31,17 @@ Examples:
 
     $ src batch repositories -f batch.spec.yaml
 
+    List the repositories as JSON, without running any steps:
+
+    $ src batch repositories -json -f batch.spec.yaml
+
 `
 
 	flagSet := flag.NewFlagSet("repositories", flag.ExitOnError)
 
 	var (
 		fileFlag = flagSet.String("f", "", "The batch spec file to read, or - to read from standard input.")
+		jsonFlag = flagSet.Bool("json", false, "Print the repositories as JSON.")
 		apiFlags = api.NewFlags(flagSet)
 	)
 
@@ -78,7 +84,7 @@ Examples:
 			file = *fileFlag
 		}
 
-		out := output.NewOutput(flagSet.Output(), output.OutputOpts{Verbose: *verbose})
+		out := output.NewOutput(statusWriter(flagSet.Output()), output.OutputOpts{Verbose: *verbose})
 		spec, _, _, err := parseBatchSpec(ctx, file, svc)
 		if err != nil {
 			ui := &ui.TUI{Out: out}
@@ -96,17 +102,29 @@ Examples:
 			return err
 		}
 
+		var skipped batchRepositoriesSkipped
 		_, repos, err := svc.ResolveWorkspacesForBatchSpec(ctx, spec, allowUnsupported, allowIgnored)
 		if err != nil {
-			if _, ok := err.(batches.UnsupportedRepoSet); ok {
+			if set, ok := err.(batches.UnsupportedRepoSet); ok {
 				// This is fine, we just ignore those in the output.
-			} else if _, ok := err.(batches.IgnoredRepoSet); ok {
+				skipped.unsupported = set
+			} else if set, ok := err.(batches.IgnoredRepoSet); ok {
 				// This is fine, we just ignore those in the output.
+				skipped.ignored = set
 			} else {
 				return errors.Wrap(err, "resolving repositories")
 			}
 		}
 
+		if *jsonFlag {
+			data, err := marshalIndent(batchRepositoriesJSON(cfg.Endpoint, repos, skipped))
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
 		repoCount := 0
 		max := 0
 		for _, repo := range repos {
@@ -172,3 +190,53 @@ type batchRepositoryTemplateInput struct {
 	Repos               []*graphql.Repository
 	SourcegraphEndpoint string
 }
+
+// batchRepositoriesSkipped are the repositories that were left out of the
+// resolved repositories.
+type batchRepositoriesSkipped struct {
+	unsupported batches.UnsupportedRepoSet
+	ignored     batches.IgnoredRepoSet
+}
+
+type batchRepositoryJSON struct {
+	Name   string `json:"name"`
+	Branch string `json:"branch,omitempty"`
+	URL    string `json:"url"`
+}
+
+type batchRepositoriesOutput struct {
+	Repositories []batchRepositoryJSON `json:"repositories"`
+	Total        int                   `json:"total"`
+	// Unsupported and Ignored are the names of the repositories that were
+	// skipped because they are on unsupported code hosts or have a
+	// .batchignore file.
+	Unsupported []string `json:"unsupported"`
+	Ignored     []string `json:"ignored"`
+}
+
+// batchRepositoriesJSON returns the JSON output of 'src batch repositories' for
+// the resolved repositories.
+func batchRepositoriesJSON(endpoint string, repos []*graphql.Repository, skipped batchRepositoriesSkipped) batchRepositoriesOutput {
+	out := batchRepositoriesOutput{
+		Repositories: []batchRepositoryJSON{},
+		Total:        len(repos),
+		Unsupported:  []string{},
+		Ignored:      []string{},
+	}
+	for _, repo := range repos {
+		out.Repositories = append(out.Repositories, batchRepositoryJSON{
+			Name:   repo.Name,
+			Branch: repo.Branch.Name,
+			URL:    endpoint + repo.URL,
+		})
+	}
+	for repo := range skipped.unsupported {
+		out.Unsupported = append(out.Unsupported, repo.Name)
+	}
+	for repo := range skipped.ignored {
+		out.Ignored = append(out.Ignored, repo.Name)
+	}
+	sort.Strings(out.Unsupported)
+	sort.Strings(out.Ignored)
+	return out
+}
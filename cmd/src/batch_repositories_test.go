@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/src-cli/internal/batches"
+	"github.com/sourcegraph/src-cli/internal/batches/graphql"
+)
+
+func TestBatchRepositoriesJSON(t *testing.T) {
+	repos := []*graphql.Repository{
+		{Name: "github.com/sourcegraph/src-cli", URL: "/github.com/sourcegraph/src-cli"},
+		{Name: "github.com/sourcegraph/sourcegraph", URL: "/github.com/sourcegraph/sourcegraph", Branch: graphql.Branch{Name: "release"}},
+	}
+	skipped := batchRepositoriesSkipped{
+		unsupported: batches.UnsupportedRepoSet{
+			{Name: "perforce.example.com/depot"}: {},
+		},
+	}
+
+	want := batchRepositoriesOutput{
+		Repositories: []batchRepositoryJSON{
+			{Name: "github.com/sourcegraph/src-cli", URL: "https://sourcegraph.com/github.com/sourcegraph/src-cli"},
+			{Name: "github.com/sourcegraph/sourcegraph", Branch: "release", URL: "https://sourcegraph.com/github.com/sourcegraph/sourcegraph"},
+		},
+		Total:       2,
+		Unsupported: []string{"perforce.example.com/depot"},
+		Ignored:     []string{},
+	}
+	if diff := cmp.Diff(want, batchRepositoriesJSON("https://sourcegraph.com", repos, skipped)); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
@@ -57,7 +57,7 @@ Examples:
 			return cmderrors.Usage("additional arguments not allowed")
 		}
 
-		out := output.NewOutput(flagSet.Output(), output.OutputOpts{Verbose: *verbose})
+		out := output.NewOutput(statusWriter(flagSet.Output()), output.OutputOpts{Verbose: *verbose})
 		ui := &ui.TUI{Out: out}
 		svc := service.New(&service.Opts{
 			Client: cfg.apiClient(apiFlags, flagSet.Output()),
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 
@@ -23,6 +24,19 @@ type command struct {
 	// handler is the function that is invoked to handle this command.
 	handler func(args []string) error
 
+	// subcommands are the commands that the handler dispatches to, if any. They
+	// are used to complete the command line.
+	subcommands *commander
+
+	// args are the values that the first argument of the command can take, if
+	// it only takes a fixed set of them. They are used to complete the command
+	// line.
+	args []string
+
+	// outputFormat is true if the command supports -output-format=json, in
+	// which case its errors are also written in the JSON envelope.
+	outputFormat bool
+
 	// flagSet.Usage function to invoke on e.g. -h flag. If nil, a default one
 	// one is used.
 	usageFunc func()
@@ -51,7 +65,11 @@ func (c commander) run(flagSet *flag.FlagSet, cmdName, usageText string, args []
 		fmt.Fprint(flag.CommandLine.Output(), usageText)
 	}
 	if !flagSet.Parsed() {
-		_ = flagSet.Parse(args)
+		defaults, err := srcFlags(flagSet)
+		if err != nil {
+			log.Fatal(err)
+		}
+		_ = flagSet.Parse(append(defaults, args...))
 	}
 
 	// Print usage if the command is "help".
@@ -86,15 +104,36 @@ func (c commander) run(flagSet *flag.FlagSet, cmdName, usageText string, args []
 		if err != nil {
 			log.Fatal("reading config: ", err)
 		}
+		if err := validateOutputFormat(); err != nil {
+			log.Printf("error: %s", err)
+			os.Exit(2)
+		}
+		if err := openLogFile(); err != nil {
+			log.Fatal(err)
+		}
 
-		// Parse subcommand flags.
+		// Parse subcommand flags, after the defaults from SRC_FLAGS.
+		defaults, err := srcFlags(cmd.flagSet)
+		if err != nil {
+			log.Fatal(err)
+		}
 		args := flagSet.Args()[1:]
-		if err := cmd.flagSet.Parse(args); err != nil {
+		if err := cmd.flagSet.Parse(append(defaults, args...)); err != nil {
 			panic(fmt.Sprintf("all registered commands should use flag.ExitOnError: error: %s", err))
 		}
 
+		logCommand(cmdName+" "+cmd.flagSet.Name(), cmd.flagSet)
+
 		// Execute the subcommand.
-		if err := cmd.handler(flagSet.Args()[1:]); err != nil {
+		start := time.Now()
+		err = cmd.handler(flagSet.Args()[1:])
+		logCommandResult(start, err)
+		if err != nil {
+			// Commands that write machine-readable output report their
+			// failure in the JSON envelope, too.
+			if e, ok := err.(*cmderrors.ExitCodeError); cmd.outputFormat && jsonOutput() && (!ok || e.HasError()) {
+				_ = writeOutputEnvelope(os.Stdout, nil, err)
+			}
 			if _, ok := err.(*cmderrors.UsageError); ok {
 				log.Printf("error: %s\n\n", err)
 				cmd.flagSet.Usage()
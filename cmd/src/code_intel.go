@@ -16,21 +16,24 @@ Usage:
 
 The commands are:
 
-    upload     uploads an LSIF dump file
+    upload     uploads an LSIF dump file, or deletes uploads with 'upload delete'
+    prune      deletes old uploads for a repository
+    summary    lists recent uploads for a repository and their processing state
 
 Use "src code-intel [command] -h" for more information about a command.
 `
 	flagSet := flag.NewFlagSet("code-intel", flag.ExitOnError)
 	handler := func(args []string) error {
-		lsifCommands.run(flagSet, "src code-intel", usage, args)
+		codeintelCommands.run(flagSet, "src code-intel", usage, args)
 		return nil
 	}
 
 	// Register the command.
 	commands = append(commands, &command{
-		flagSet: flagSet,
-		aliases: []string{"code-intel"},
-		handler: handler,
+		flagSet:     flagSet,
+		aliases:     []string{"code-intel"},
+		handler:     handler,
+		subcommands: &codeintelCommands,
 		usageFunc: func() {
 			fmt.Println(usage)
 		},
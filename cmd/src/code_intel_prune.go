@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/src-cli/internal/api"
+	"github.com/sourcegraph/src-cli/internal/cmderrors"
+)
+
+func init() {
+	usage := `
+Examples:
+
+  Preview the uploads for a repository that are older than 30 days:
+
+    	$ src code-intel prune -repo=github.com/gorilla/mux -older-than=30d -dry-run
+
+  Delete them without prompting for confirmation:
+
+    	$ src code-intel prune -repo=github.com/gorilla/mux -older-than=30d -force
+
+  Uploads that are the latest for their repository are kept unless -include-latest is set.
+`
+
+	flagSet := flag.NewFlagSet("prune", flag.ExitOnError)
+	usageFunc := func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of 'src code-intel %s':\n", flagSet.Name())
+		flagSet.PrintDefaults()
+		fmt.Println(usage)
+	}
+	var (
+		repoFlag          = flagSet.String("repo", "", `The name of the repository to prune uploads for (e.g. github.com/gorilla/mux). (required)`)
+		olderThanFlag     = flagSet.String("older-than", "", `Delete uploads uploaded longer ago than this (e.g. "30d", "12h"). (required)`)
+		includeLatestFlag = flagSet.Bool("include-latest", false, `Also delete uploads that are the latest for their repository.`)
+		dryRunFlag        = flagSet.Bool("dry-run", false, `Only list the uploads that would be deleted.`)
+		forceFlag         = flagSet.Bool("force", false, `Skip the confirmation prompt.`)
+		apiFlags          = api.NewFlags(flagSet)
+	)
+
+	handler := func(args []string) error {
+		if err := flagSet.Parse(args); err != nil {
+			return err
+		}
+		if *repoFlag == "" {
+			return cmderrors.Usage("-repo must be specified")
+		}
+		if *olderThanFlag == "" {
+			return cmderrors.Usage("-older-than must be specified")
+		}
+		olderThan, err := parseAge(*olderThanFlag)
+		if err != nil {
+			return cmderrors.Usagef("invalid -older-than: %s", err)
+		}
+
+		ctx := context.Background()
+		client := cfg.apiClient(apiFlags, flagSet.Output())
+
+		uploads, err := listCodeIntelUploads(ctx, client, *repoFlag)
+		if err != nil {
+			return err
+		}
+		uploads = selectCodeIntelUploadsToPrune(uploads, time.Now().Add(-olderThan), *includeLatestFlag)
+		if len(uploads) == 0 {
+			fmt.Printf("No uploads for %s are older than %s.\n", *repoFlag, *olderThanFlag)
+			return nil
+		}
+
+		return deleteCodeIntelUploads(ctx, client, uploads, *dryRunFlag, *forceFlag)
+	}
+
+	codeintelCommands = append(codeintelCommands, &command{
+		flagSet:   flagSet,
+		handler:   handler,
+		usageFunc: usageFunc,
+	})
+}
+
+// codeintelUploadCommands are the subcommands of `src code-intel upload`. They are
+// dispatched from handleCodeIntelUpload, since 'upload' is not a commander.
+var codeintelUploadCommands commander
+
+func init() {
+	usage := `
+Examples:
+
+  Delete an upload by ID, as shown in the upload URL or by 'src code-intel prune -dry-run':
+
+    	$ src code-intel upload delete TFNJRlVwbG9hZDoxMjM=
+
+  Uploads can also be given by their numeric database ID:
+
+    	$ src code-intel upload delete -force 123 124
+`
+
+	flagSet := flag.NewFlagSet("delete", flag.ExitOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of 'src code-intel upload %s':\n", flagSet.Name())
+		flagSet.PrintDefaults()
+		fmt.Println(usage)
+	}
+	var (
+		dryRunFlag = flagSet.Bool("dry-run", false, `Only list the uploads that would be deleted.`)
+		forceFlag  = flagSet.Bool("force", false, `Skip the confirmation prompt.`)
+		apiFlags   = api.NewFlags(flagSet)
+	)
+
+	handler := func(args []string) error {
+		if err := flagSet.Parse(args); err != nil {
+			return err
+		}
+		if flagSet.NArg() == 0 {
+			return cmderrors.Usage("expected one or more upload IDs")
+		}
+
+		ctx := context.Background()
+		client := cfg.apiClient(apiFlags, flagSet.Output())
+
+		var uploads []codeintelUpload
+		for _, id := range flagSet.Args() {
+			upload, err := getCodeIntelUpload(ctx, client, codeintelUploadGraphQLID(id))
+			if err != nil {
+				return err
+			}
+			uploads = append(uploads, upload)
+		}
+
+		return deleteCodeIntelUploads(ctx, client, uploads, *dryRunFlag, *forceFlag)
+	}
+
+	codeintelUploadCommands = append(codeintelUploadCommands, &command{
+		flagSet: flagSet,
+		handler: handler,
+	})
+}
+
+// codeintelUpload is a precise code intelligence upload as returned by the GraphQL API.
+type codeintelUpload struct {
+	ID          string
+	ProjectRoot struct {
+		Repository struct {
+			Name string
+		}
+	}
+	InputCommit     string
+	InputRoot       string
+	InputIndexer    string
+	State           string
+	UploadedAt      time.Time
+	StartedAt       *time.Time
+	FinishedAt      *time.Time
+	Failure         *string
+	IsLatestForRepo bool
+}
+
+const codeintelUploadFields = `
+fragment CodeIntelUploadFields on LSIFUpload {
+	id
+	projectRoot {
+		repository {
+			name
+		}
+	}
+	inputCommit
+	inputRoot
+	inputIndexer
+	state
+	uploadedAt
+	startedAt
+	finishedAt
+	failure
+	isLatestForRepo
+}
+`
+
+const listCodeIntelUploadsQuery = `query CodeIntelUploads($repo: String!, $first: Int, $after: String) {
+	repository(name: $repo) {
+		lsifUploads(first: $first, after: $after) {
+			nodes {
+				...CodeIntelUploadFields
+			}
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+		}
+	}
+}
+` + codeintelUploadFields
+
+// listCodeIntelUploads returns all uploads for the given repository.
+func listCodeIntelUploads(ctx context.Context, client api.Client, repo string) ([]codeintelUpload, error) {
+	var (
+		uploads []codeintelUpload
+		after   *string
+	)
+	for {
+		var result struct {
+			Repository *struct {
+				LSIFUploads struct {
+					Nodes    []codeintelUpload
+					PageInfo struct {
+						HasNextPage bool
+						EndCursor   *string
+					}
+				}
+			}
+		}
+		if ok, err := client.NewRequest(listCodeIntelUploadsQuery, map[string]interface{}{
+			"repo":  repo,
+			"first": 100,
+			"after": after,
+		}).Do(ctx, &result); err != nil || !ok {
+			return nil, err
+		}
+		if result.Repository == nil {
+			return nil, errors.Newf("repository %q not found", repo)
+		}
+
+		uploads = append(uploads, result.Repository.LSIFUploads.Nodes...)
+		pageInfo := result.Repository.LSIFUploads.PageInfo
+		if !pageInfo.HasNextPage || pageInfo.EndCursor == nil {
+			return uploads, nil
+		}
+		after = pageInfo.EndCursor
+	}
+}
+
+// getCodeIntelUpload returns the upload with the given GraphQL ID.
+func getCodeIntelUpload(ctx context.Context, client api.Client, id string) (codeintelUpload, error) {
+	query := `query CodeIntelUpload($id: ID!) {
+	node(id: $id) {
+		... on LSIFUpload {
+			...CodeIntelUploadFields
+		}
+	}
+}
+` + codeintelUploadFields
+
+	var result struct {
+		Node *codeintelUpload
+	}
+	if ok, err := client.NewRequest(query, map[string]interface{}{
+		"id": id,
+	}).Do(ctx, &result); err != nil || !ok {
+		return codeintelUpload{}, err
+	}
+	if result.Node == nil || result.Node.ID == "" {
+		return codeintelUpload{}, errors.Newf("upload %q not found", id)
+	}
+	return *result.Node, nil
+}
+
+// codeintelUploadGraphQLID returns the GraphQL ID for the given upload ID, which may
+// either already be a GraphQL ID or a numeric database ID.
+func codeintelUploadGraphQLID(id string) string {
+	if _, err := strconv.Atoi(id); err != nil {
+		return id
+	}
+	return base64.URLEncoding.EncodeToString([]byte("LSIFUpload:" + id))
+}
+
+// selectCodeIntelUploadsToPrune returns the uploads that were uploaded before the
+// cutoff. Uploads that are the latest for their repository are only included if
+// includeLatest is set.
+func selectCodeIntelUploadsToPrune(uploads []codeintelUpload, cutoff time.Time, includeLatest bool) []codeintelUpload {
+	var selected []codeintelUpload
+	for _, u := range uploads {
+		if !u.UploadedAt.Before(cutoff) {
+			continue
+		}
+		if u.IsLatestForRepo && !includeLatest {
+			continue
+		}
+		selected = append(selected, u)
+	}
+	return selected
+}
+
+// deleteCodeIntelUploads lists the given uploads and, unless dryRun is set, deletes
+// them after confirmation from the user. The confirmation is skipped if force is set.
+func deleteCodeIntelUploads(ctx context.Context, client api.Client, uploads []codeintelUpload, dryRun, force bool) error {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"ID", "Repository", "Commit", "Root", "Indexer", "State", "Uploaded"})
+	for _, u := range uploads {
+		commit := u.InputCommit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		t.AppendRow(table.Row{u.ID, u.ProjectRoot.Repository.Name, commit, u.InputRoot, u.InputIndexer, u.State, u.UploadedAt.Format(time.RFC3339)})
+	}
+	t.SetStyle(table.StyleRounded)
+	t.Render()
+
+	if dryRun {
+		fmt.Printf("%d uploads would be deleted from %s.\n", len(uploads), cfg.Endpoint)
+		return nil
+	}
+
+	if !force {
+		confirmed, err := verify(fmt.Sprintf("Do you wish to delete these %d uploads from %s", len(uploads), cfg.Endpoint))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Aborting deletion.")
+			return nil
+		}
+	}
+
+	deleted, err := deleteCodeIntelUploadsByID(ctx, client, uploads)
+	fmt.Printf("%d uploads deleted.\n", deleted)
+	return err
+}
+
+const deleteLSIFUploadMutation = `mutation DeleteLSIFUpload($id: ID!) {
+	deleteLSIFUpload(id: $id) {
+		alwaysNil
+	}
+}`
+
+// deleteCodeIntelUploadsByID deletes the given uploads in order, stopping at the
+// first that fails to be deleted. It returns the number of uploads deleted.
+func deleteCodeIntelUploadsByID(ctx context.Context, client api.Client, uploads []codeintelUpload) (int, error) {
+	for i, u := range uploads {
+		var result struct {
+			DeleteLSIFUpload struct{}
+		}
+		if ok, err := client.NewRequest(deleteLSIFUploadMutation, map[string]interface{}{
+			"id": u.ID,
+		}).Do(ctx, &result); err != nil {
+			return i, errors.Wrapf(err, "deleting upload %q", u.ID)
+		} else if !ok {
+			return i, nil
+		}
+	}
+	return len(uploads), nil
+}
+
+// parseAge parses a duration as accepted by time.ParseDuration, additionally
+// accepting a number of days with a "d" suffix (e.g. "30d").
+func parseAge(s string) (time.Duration, error) {
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, errors.Newf("invalid number of days %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, errors.Newf("duration %q must not be negative", s)
+	}
+	return d, nil
+}
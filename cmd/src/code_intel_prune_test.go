@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	mockapi "github.com/sourcegraph/src-cli/internal/api/mock"
+)
+
+func TestParseAge(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"0d":  0,
+		"12h": 12 * time.Hour,
+		"90m": 90 * time.Minute,
+	} {
+		got, err := parseAge(input)
+		if err != nil {
+			t.Errorf("parseAge(%q): unexpected error: %s", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("parseAge(%q): want %s, got %s", input, want, got)
+		}
+	}
+
+	for _, input := range []string{"", "d", "-1d", "1.5d", "-2h", "thirty"} {
+		if _, err := parseAge(input); err == nil {
+			t.Errorf("parseAge(%q): expected error", input)
+		}
+	}
+}
+
+func TestSelectCodeIntelUploadsToPrune(t *testing.T) {
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	uploads := []codeintelUpload{
+		{ID: "old", UploadedAt: now.Add(-40 * 24 * time.Hour)},
+		{ID: "old-latest", UploadedAt: now.Add(-40 * 24 * time.Hour), IsLatestForRepo: true},
+		{ID: "new", UploadedAt: now.Add(-10 * 24 * time.Hour)},
+	}
+	cutoff := now.Add(-30 * 24 * time.Hour)
+
+	ids := func(uploads []codeintelUpload) []string {
+		var ids []string
+		for _, u := range uploads {
+			ids = append(ids, u.ID)
+		}
+		return ids
+	}
+
+	if diff := cmp.Diff([]string{"old"}, ids(selectCodeIntelUploadsToPrune(uploads, cutoff, false))); diff != "" {
+		t.Errorf("unexpected uploads (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"old", "old-latest"}, ids(selectCodeIntelUploadsToPrune(uploads, cutoff, true))); diff != "" {
+		t.Errorf("unexpected uploads with -include-latest (-want +got):\n%s", diff)
+	}
+}
+
+func TestDeleteCodeIntelUploadsByID(t *testing.T) {
+	client := &mockapi.Client{}
+	for id, err := range map[string]error{"a": nil, "b": errors.New("boom")} {
+		req := &mockapi.Request{}
+		req.On("Do", mock.Anything, mock.Anything).Return(err == nil, err).Once()
+		client.On("NewRequest", deleteLSIFUploadMutation, map[string]interface{}{"id": id}).Return(req).Once()
+	}
+
+	deleted, err := deleteCodeIntelUploadsByID(context.Background(), client, []codeintelUpload{{ID: "a"}, {ID: "b"}, {ID: "c"}})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if deleted != 1 {
+		t.Errorf("deleted %d uploads, want 1", deleted)
+	}
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "NewRequest", deleteLSIFUploadMutation, map[string]interface{}{"id": "c"})
+}
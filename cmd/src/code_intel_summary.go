@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/src-cli/internal/api"
+	"github.com/sourcegraph/src-cli/internal/cmderrors"
+)
+
+func init() {
+	usage := `
+Examples:
+
+  List the most recent uploads for a repository and their processing state:
+
+    	$ src code-intel summary -repo=github.com/gorilla/mux
+
+  Wait for an upload to be processed, e.g. in CI after 'src code-intel upload'. The
+  command exits with a non-zero status if processing fails:
+
+    	$ src code-intel summary -watch=123
+
+  Upload IDs may be given as the numeric ID printed by 'src code-intel upload', or as
+  GraphQL IDs.
+`
+
+	flagSet := flag.NewFlagSet("summary", flag.ExitOnError)
+	usageFunc := func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of 'src code-intel %s':\n", flagSet.Name())
+		flagSet.PrintDefaults()
+		fmt.Println(usage)
+	}
+	var (
+		repoFlag     = flagSet.String("repo", "", `The name of the repository to list uploads for (e.g. github.com/gorilla/mux).`)
+		firstFlag    = flagSet.Int("first", 10, `The number of most recent uploads to list.`)
+		watchFlag    = flagSet.String("watch", "", `The ID of an upload to poll until it has been processed or has failed.`)
+		intervalFlag = flagSet.Duration("interval", 5*time.Second, `How often to poll the upload given with -watch.`)
+		jsonFlag     = flagSet.Bool("json", false, `Print the uploads as JSON.`)
+		apiFlags     = api.NewFlags(flagSet)
+	)
+
+	handler := func(args []string) error {
+		if err := flagSet.Parse(args); err != nil {
+			return err
+		}
+		if (*repoFlag == "") == (*watchFlag == "") {
+			return cmderrors.Usage("exactly one of -repo or -watch must be specified")
+		}
+		if *firstFlag <= 0 {
+			return cmderrors.Usage("-first must be positive")
+		}
+		if *intervalFlag <= 0 {
+			return cmderrors.Usage("-interval must be positive")
+		}
+
+		ctx := context.Background()
+		client := cfg.apiClient(apiFlags, flagSet.Output())
+
+		if *watchFlag != "" {
+			upload, err := watchCodeIntelUpload(ctx, client, codeintelUploadGraphQLID(*watchFlag), *intervalFlag, !*jsonFlag)
+			if err != nil {
+				return err
+			}
+			if err := printCodeIntelUploads([]codeintelUpload{upload}, *jsonFlag); err != nil {
+				return err
+			}
+			if upload.State == "ERRORED" {
+				return cmderrors.ExitCode(1, errors.Newf("upload %s failed", upload.ID))
+			}
+			return nil
+		}
+
+		uploads, err := listRecentCodeIntelUploads(ctx, client, *repoFlag, *firstFlag)
+		if err != nil {
+			return err
+		}
+		if len(uploads) == 0 && !*jsonFlag {
+			fmt.Printf("No uploads found for %s.\n", *repoFlag)
+			return nil
+		}
+		return printCodeIntelUploads(uploads, *jsonFlag)
+	}
+
+	codeintelCommands = append(codeintelCommands, &command{
+		flagSet:   flagSet,
+		handler:   handler,
+		usageFunc: usageFunc,
+	})
+}
+
+// listRecentCodeIntelUploads returns the first uploads for the given repository, most
+// recent first.
+func listRecentCodeIntelUploads(ctx context.Context, client api.Client, repo string, first int) ([]codeintelUpload, error) {
+	var result struct {
+		Repository *struct {
+			LSIFUploads struct {
+				Nodes []codeintelUpload
+			}
+		}
+	}
+	if ok, err := client.NewRequest(listCodeIntelUploadsQuery, map[string]interface{}{
+		"repo":  repo,
+		"first": first,
+	}).Do(ctx, &result); err != nil || !ok {
+		return nil, err
+	}
+	if result.Repository == nil {
+		return nil, errors.Newf("repository %q not found", repo)
+	}
+	return result.Repository.LSIFUploads.Nodes, nil
+}
+
+// watchCodeIntelUpload polls the upload with the given ID until it reaches a terminal
+// state, and returns it. State changes are printed if verbose is set.
+func watchCodeIntelUpload(ctx context.Context, client api.Client, id string, interval time.Duration, verbose bool) (codeintelUpload, error) {
+	var lastState string
+	for {
+		upload, err := getCodeIntelUpload(ctx, client, id)
+		if err != nil {
+			return upload, err
+		}
+		if verbose && upload.State != lastState {
+			fmt.Printf("%s Upload %s is %s.\n", time.Now().Format(time.RFC3339), upload.ID, upload.State)
+			lastState = upload.State
+		}
+		if codeintelUploadStateIsTerminal(upload.State) {
+			return upload, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return upload, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// codeintelUploadStateIsTerminal reports whether an upload in the given state will
+// not be processed further.
+func codeintelUploadStateIsTerminal(state string) bool {
+	switch state {
+	case "COMPLETED", "ERRORED", "DELETING", "DELETED":
+		return true
+	}
+	return false
+}
+
+// printCodeIntelUploads prints the uploads as a table, or as JSON if asJSON is set.
+func printCodeIntelUploads(uploads []codeintelUpload, asJSON bool) error {
+	if asJSON {
+		if uploads == nil {
+			uploads = []codeintelUpload{}
+		}
+		data, err := marshalIndent(uploads)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"ID", "Commit", "Root", "Indexer", "State", "Uploaded", "Started", "Finished"})
+	for _, u := range uploads {
+		commit := u.InputCommit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		t.AppendRow(table.Row{u.ID, commit, u.InputRoot, u.InputIndexer, u.State, u.UploadedAt.Format(time.RFC3339), formatTime(u.StartedAt), formatTime(u.FinishedAt)})
+	}
+	t.SetStyle(table.StyleRounded)
+	t.Render()
+
+	for _, u := range uploads {
+		if u.State == "ERRORED" && u.Failure != nil {
+			fmt.Printf("Upload %s failed: %s\n", u.ID, *u.Failure)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/src-cli/internal/api"
+)
+
+func TestWatchCodeIntelUpload(t *testing.T) {
+	states := []string{"QUEUED", "PROCESSING", "COMPLETED"}
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := states[requests]
+		requests++
+		w.Write([]byte(`{"data": {"node": {"id": "TFNJRlVwbG9hZDoxMjM=", "state": "` + state + `"}}}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	client := api.NewClient(api.ClientOpts{Endpoint: ts.URL, Out: io.Discard})
+	upload, err := watchCodeIntelUpload(context.Background(), client, codeintelUploadGraphQLID("123"), time.Millisecond, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upload.State != "COMPLETED" {
+		t.Errorf("unexpected state %q", upload.State)
+	}
+	if requests != len(states) {
+		t.Errorf("unexpected number of requests: want %d, got %d", len(states), requests)
+	}
+}
@@ -13,8 +13,10 @@ import (
 	"time"
 
 	"github.com/pkg/browser"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/sourcegraph/sourcegraph/lib/codeintel/upload"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
 	"github.com/sourcegraph/sourcegraph/lib/output"
 
 	"github.com/sourcegraph/src-cli/internal/api"
@@ -32,6 +34,11 @@ Examples:
 
     	$ src code-intel upload -root=cmd/
 
+  Upload a SCIP index for a subproject of a monorepo, for the commit at the tip
+  of a branch. The commit must have been pushed and synced by Sourcegraph:
+
+    	$ src code-intel upload -root=services/billing -commit=main -file=services/billing/index.scip
+
   Upload a SCIP index when lsifEnforceAuth is enabled:
 
     	$ src code-intel upload -github-token=BAZ, or
@@ -43,10 +50,27 @@ Examples:
 
   For any of these commands, an LSIF index (default name: dump.lsif) can be
   used instead of a SCIP index (default name: index.scip).
+
+  Upload several SCIP indexes for the same commit, e.g. one per language. The
+  root of each upload is inferred from the location of its index file:
+
+    	$ src code-intel upload -file=go/index.scip -file=web/index.scip
+    	$ src code-intel upload -file='*/index.scip'
+
+  Upload a SCIP index read from standard input, or downloaded from a URL. The
+  index is uploaded for the repository root unless -root is given:
+
+    	$ ./build-index | src code-intel upload -file=-
+    	$ src code-intel upload -file=https://artifacts.example.com/builds/123/index.scip
+
+  Delete a previous upload by ID (see 'src code-intel upload delete -h'):
+
+    	$ src code-intel upload delete TFNJRlVwbG9hZDoxMjM=
 `
 	codeintelCommands = append(codeintelCommands, &command{
-		flagSet: codeintelUploadFlagSet,
-		handler: handleCodeIntelUpload,
+		flagSet:     codeintelUploadFlagSet,
+		handler:     handleCodeIntelUpload,
+		subcommands: &codeintelUploadCommands,
 		usageFunc: func() {
 			fmt.Fprintf(flag.CommandLine.Output(), "Usage of 'src code-intel %s':\n", codeintelUploadFlagSet.Name())
 			codeintelUploadFlagSet.PrintDefaults()
@@ -56,8 +80,9 @@ Examples:
 
 	// Make 'upload' available under 'src lsif' for backwards compatibility.
 	lsifCommands = append(lsifCommands, &command{
-		flagSet: codeintelUploadFlagSet,
-		handler: handleCodeIntelUpload,
+		flagSet:     codeintelUploadFlagSet,
+		handler:     handleCodeIntelUpload,
+		subcommands: &codeintelUploadCommands,
 		usageFunc: func() {
 			fmt.Fprintf(flag.CommandLine.Output(), "Usage of 'src lsif %s':\n", codeintelUploadFlagSet.Name())
 			codeintelUploadFlagSet.PrintDefaults()
@@ -68,10 +93,21 @@ Examples:
 
 // handleCodeIntelUpload is the handler for `src code-intel upload`.
 func handleCodeIntelUpload(args []string) error {
+	if len(args) > 0 {
+		for _, cmd := range codeintelUploadCommands {
+			if cmd.matches(args[0]) {
+				return cmd.handler(args[1:])
+			}
+		}
+	}
+
 	ctx := context.Background()
 
-	out, err := parseAndValidateCodeIntelUploadFlags(args)
-	if !codeintelUploadFlags.json {
+	out, targets, err := parseAndValidateCodeIntelUploadFlags(args)
+	if codeintelUploadFlags.sourceDir != "" {
+		defer os.RemoveAll(codeintelUploadFlags.sourceDir)
+	}
+	if !codeintelUploadFlags.json && (err != nil || len(targets) == 1) {
 		if out != nil {
 			printInferredArguments(out)
 		} else {
@@ -83,10 +119,19 @@ func handleCodeIntelUpload(args []string) error {
 		return handleUploadError(nil, err)
 	}
 
-	client := api.NewClient(api.ClientOpts{
-		Out:   io.Discard,
-		Flags: codeintelUploadFlags.apiFlags,
-	})
+	// The upload requests are sent to the URL in the upload options, but the
+	// commit check is a GraphQL request to the configured endpoint.
+	client := cfg.apiClient(codeintelUploadFlags.apiFlags, io.Discard)
+
+	if !codeintelUploadFlags.skipCommitCheck {
+		if err := checkCodeIntelUploadCommit(ctx, client, codeintelUploadFlags.repo, codeintelUploadFlags.commit); err != nil {
+			return handleUploadError(out, err)
+		}
+	}
+
+	if len(targets) > 1 {
+		return uploadCodeIntelIndexes(ctx, out, client, targets)
+	}
 
 	uploadID, err := upload.UploadIndex(ctx, codeintelUploadFlags.file, client, codeintelUploadOptions(out))
 	if err != nil {
@@ -99,11 +144,15 @@ func handleCodeIntelUpload(args []string) error {
 	}
 
 	if codeintelUploadFlags.json {
+		file := codeintelUploadFlags.file
+		if codeintelUploadFlags.source != "" {
+			file = codeintelUploadFlags.source
+		}
 		serialized, err := json.Marshal(map[string]interface{}{
 			"repo":           codeintelUploadFlags.repo,
 			"commit":         codeintelUploadFlags.commit,
 			"root":           codeintelUploadFlags.root,
-			"file":           codeintelUploadFlags.file,
+			"file":           file,
 			"indexer":        codeintelUploadFlags.indexer,
 			"indexerVersion": codeintelUploadFlags.indexerVersion,
 			"uploadId":       uploadID,
@@ -131,6 +180,86 @@ func handleCodeIntelUpload(args []string) error {
 	return nil
 }
 
+// codeintelUploadResult is the outcome of uploading one of multiple index files.
+type codeintelUploadResult struct {
+	File      string `json:"file"`
+	Root      string `json:"root"`
+	Indexer   string `json:"indexer"`
+	UploadID  int    `json:"uploadId,omitempty"`
+	UploadURL string `json:"uploadUrl,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// uploadCodeIntelIndexes uploads multiple index files for the same repository and
+// commit with a shared client, at most -j at a time. Per-file progress is not
+// displayed; instead, the upload ID of each file is reported once all uploads are
+// complete.
+func uploadCodeIntelIndexes(ctx context.Context, out *output.Output, client upload.Client, targets []codeintelUploadTarget) error {
+	results := make([]codeintelUploadResult, len(targets))
+
+	var g errgroup.Group
+	g.SetLimit(codeintelUploadFlags.parallelism)
+	for i, t := range targets {
+		i, t := i, t
+		g.Go(func() error {
+			opts := codeintelUploadOptions(nil)
+			opts.Root = t.root
+			opts.Indexer = t.indexer
+			opts.IndexerVersion = t.indexerVersion
+
+			results[i] = codeintelUploadResult{File: t.file, Root: t.root, Indexer: t.indexer}
+			uploadID, err := upload.UploadIndex(ctx, t.file, client, opts)
+			if err != nil {
+				results[i].Error = err.Error()
+				return nil
+			}
+			results[i].UploadID = uploadID
+			results[i].UploadURL, err = makeCodeIntelUploadURL(uploadID)
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+
+	if codeintelUploadFlags.json {
+		serialized, err := json.Marshal(map[string]interface{}{
+			"repo":    codeintelUploadFlags.repo,
+			"commit":  codeintelUploadFlags.commit,
+			"uploads": results,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(serialized))
+	} else {
+		if out == nil {
+			out = emergencyOutput()
+		}
+		for _, r := range results {
+			if r.Error != "" {
+				out.WriteLine(output.Linef(output.EmojiFailure, output.StyleFailure, "%s: %s", r.File, r.Error))
+				continue
+			}
+			out.WriteLine(output.Linef(output.EmojiSuccess, output.StyleSuccess, "%s (root %q, indexer %s): upload %d", r.File, r.Root, r.Indexer, r.UploadID))
+			out.WriteLine(output.Linef(output.EmojiLightbulb, output.StyleItalic, "View processing status at %s", r.UploadURL))
+		}
+		out.WriteLine(output.Linef("", output.StyleBold, "Uploaded %d of %d index files to %s@%s.", len(results)-failed, len(results), codeintelUploadFlags.repo, codeintelUploadFlags.commit))
+	}
+
+	if failed > 0 {
+		return handleUploadError(out, errors.Newf("%d of %d index files failed to upload", failed, len(results)))
+	}
+	return nil
+}
+
 // codeintelUploadOptions creates a set of upload options given the values in the flags.
 func codeintelUploadOptions(out *output.Output) upload.UploadOptions {
 	var associatedIndexID *int
@@ -206,6 +335,48 @@ func makeCodeIntelUploadURL(uploadID int) (string, error) {
 	return url.String(), nil
 }
 
+// checkCodeIntelUploadCommit returns an error if the commit is not known to the
+// Sourcegraph instance, as uploads for it would not be processed until it is.
+func checkCodeIntelUploadCommit(ctx context.Context, client api.Client, repo, commit string) error {
+	query := `query CodeIntelUploadCommit($repo: String!, $commit: String!) {
+	repository(name: $repo) {
+		commit(rev: $commit) {
+			oid
+		}
+	}
+}`
+	var result struct {
+		Repository *struct {
+			Commit *struct {
+				OID string
+			}
+		}
+	}
+	if ok, err := client.NewRequest(query, map[string]interface{}{
+		"repo":   repo,
+		"commit": commit,
+	}).Do(ctx, &result); err != nil || !ok {
+		return err
+	}
+
+	if result.Repository == nil {
+		return errorWithHint{
+			err:  errors.Newf("repository %q not found on the Sourcegraph instance", repo),
+			hint: "Check the value of -repo, and that the repository has been added to Sourcegraph.",
+		}
+	}
+	if result.Repository.Commit == nil {
+		return errorWithHint{
+			err: errors.Newf("commit %s of %s is not known to the Sourcegraph instance", commit, repo),
+			hint: strings.Join([]string{
+				fmt.Sprintf("Push the commit to the code host and wait for Sourcegraph to sync %s before uploading.", repo),
+				"Use -skip-commit-check to upload anyway.",
+			}, "\n"),
+		}
+	}
+	return nil
+}
+
 type errorWithHint struct {
 	err  error
 	hint string
@@ -278,7 +449,7 @@ func filterLSIFUnauthorizedError(out *output.Output, err error) error {
 
 // emergencyOutput creates a default Output object writing to standard out.
 func emergencyOutput() *output.Output {
-	return output.NewOutput(os.Stdout, output.OutputOpts{})
+	return output.NewOutput(statusWriter(os.Stdout), output.OutputOpts{})
 }
 
 func mergeStringSlices(ss ...[]string) []string {
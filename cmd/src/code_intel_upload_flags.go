@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -20,7 +21,15 @@ import (
 )
 
 var codeintelUploadFlags struct {
-	file string
+	// files are the index files given with -file. file is the index file that is
+	// currently being prepared or uploaded.
+	files       stringSliceFlag
+	file        string
+	parallelism int
+	// source is the -file value when the index is read from standard input or
+	// a URL, in which case file is a copy of it in sourceDir.
+	source    string
+	sourceDir string
 
 	// UploadRecordOptions
 	repo              string
@@ -29,6 +38,7 @@ var codeintelUploadFlags struct {
 	indexer           string
 	indexerVersion    string
 	associatedIndexID int
+	skipCommitCheck   bool
 
 	// SourcegraphInstanceOptions
 	uploadRoute      string
@@ -56,11 +66,13 @@ var (
 )
 
 func init() {
-	codeintelUploadFlagSet.StringVar(&codeintelUploadFlags.file, "file", "./dump.lsif", `The path to the LSIF dump file.`)
+	codeintelUploadFlagSet.Var(&codeintelUploadFlags.files, "file", `The path to the index file (default "./dump.lsif"). May be given multiple times or as a glob pattern (e.g. "*.scip") to upload several index files. Use - to read the index from standard input, or an http:// or https:// URL to download it.`)
+	codeintelUploadFlagSet.IntVar(&codeintelUploadFlags.parallelism, "j", 4, `The maximum number of index files to upload in parallel when multiple are given.`)
 
 	// UploadRecordOptions
 	codeintelUploadFlagSet.StringVar(&codeintelUploadFlags.repo, "repo", "", `The name of the repository (e.g. github.com/gorilla/mux). By default, derived from the origin remote.`)
-	codeintelUploadFlagSet.StringVar(&codeintelUploadFlags.commit, "commit", "", `The 40-character hash of the commit. Defaults to the currently checked-out commit.`)
+	codeintelUploadFlagSet.StringVar(&codeintelUploadFlags.commit, "commit", "", `The 40-character hash of the commit, or a ref such as a branch or tag that is resolved in the local clone. Defaults to the currently checked-out commit.`)
+	codeintelUploadFlagSet.BoolVar(&codeintelUploadFlags.skipCommitCheck, "skip-commit-check", false, `Upload without checking that the commit is known to the Sourcegraph instance.`)
 	codeintelUploadFlagSet.StringVar(&codeintelUploadFlags.root, "root", "", `The path in the repository that matches the LSIF projectRoot (e.g. cmd/project1). Defaults to the directory where the dump file is located.`)
 	codeintelUploadFlagSet.StringVar(&codeintelUploadFlags.indexer, "indexer", "", `The name of the indexer that generated the dump. This will override the 'toolInfo.name' field in the metadata vertex of the LSIF dump file. This must be supplied if the indexer does not set this field (in which case the upload will fail with an explicit message).`)
 	codeintelUploadFlagSet.StringVar(&codeintelUploadFlags.indexerVersion, "indexerVersion", "", `The version of the indexer that generated the dump. This will override the 'toolInfo.version' field in the metadata vertex of the LSIF dump file. This must be supplied if the indexer does not set this field (in which case the upload will fail with an explicit message).`)
@@ -83,15 +95,25 @@ func init() {
 	codeintelUploadFlagSet.BoolVar(&dummyflag, "insecure-skip-verify", false, "Skip validation of TLS certificates against trusted chains")
 }
 
+// codeintelUploadTarget holds the flag values that are specific to each
+// uploaded index file.
+type codeintelUploadTarget struct {
+	file           string
+	root           string
+	indexer        string
+	indexerVersion string
+}
+
 // parseAndValidateCodeIntelUploadFlags calls codeintelUploadFlagset.Parse, then infers values for
 // missing flags, normalizes supplied values, and validates the state of the codeintelUploadFlags
-// object.
+// object for each index file given with -file.
 //
-// On success, the global codeintelUploadFlags object will be populated with valid values. An
-// error is returned on failure.
-func parseAndValidateCodeIntelUploadFlags(args []string) (*output.Output, error) {
+// On success, the global codeintelUploadFlags object will be populated with valid values for
+// the last index file, and the values for each index file are returned. An error is returned
+// on failure, in which case the global object describes the index file that failed.
+func parseAndValidateCodeIntelUploadFlags(args []string) (*output.Output, []codeintelUploadTarget, error) {
 	if err := codeintelUploadFlagSet.Parse(args); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	out := codeintelUploadOutput()
@@ -109,30 +131,105 @@ func parseAndValidateCodeIntelUploadFlags(args []string) (*output.Output, error)
 	// and maybe we'll use some in the future
 	codeintelUploadFlags.apiFlags = api.NewFlags(apiClientFlagSet)
 	if err := apiClientFlagSet.Parse(insecureSkipVerifyFlag); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	if err := handleSCIP(out); err != nil {
-		return nil, err
+	files, err := expandCodeIntelUploadFiles(codeintelUploadFlags.files)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(files) > 1 && isFlagSet(codeintelUploadFlagSet, "root") {
+		return nil, nil, errors.New("-root cannot be used with multiple index files, as it is inferred from the location of each file")
 	}
+	if codeintelUploadFlags.parallelism < 1 {
+		return nil, nil, errors.New("-j must be at least 1")
+	}
+	for _, file := range files {
+		if !isStreamedCodeIntelUploadSource(file) {
+			continue
+		}
+		if len(files) > 1 {
+			return nil, nil, errors.New("-file=- and -file=URL cannot be used with multiple index files")
+		}
+		codeintelUploadFlags.source = file
+		src, err := openCodeIntelUploadSource(context.Background(), file)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer src.Close()
+		dir, err := os.MkdirTemp("", "src-code-intel-upload-")
+		if err != nil {
+			return nil, nil, err
+		}
+		codeintelUploadFlags.sourceDir = dir
+		if files[0], err = src.spool(dir); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var targets []codeintelUploadTarget
+	for _, file := range files {
+		codeintelUploadFlags.file = file
+
+		if err := handleSCIP(out); err != nil {
+			return nil, nil, err
+		}
+
+		if inferenceErrors := inferMissingCodeIntelUploadFlags(); len(inferenceErrors) > 0 {
+			return nil, nil, errorWithHint{
+				err: inferenceErrors[0].err, hint: strings.Join([]string{
+					fmt.Sprintf(
+						"Unable to determine %s from environment. Check your working directory or supply -%s={value} explicitly",
+						inferenceErrors[0].argument,
+						inferenceErrors[0].argument,
+					),
+				}, "\n"),
+			}
+		}
 
-	if inferenceErrors := inferMissingCodeIntelUploadFlags(); len(inferenceErrors) > 0 {
-		return nil, errorWithHint{
-			err: inferenceErrors[0].err, hint: strings.Join([]string{
-				fmt.Sprintf(
-					"Unable to determine %s from environment. Check your working directory or supply -%s={value} explicitly",
-					inferenceErrors[0].argument,
-					inferenceErrors[0].argument,
-				),
-			}, "\n"),
+		if err := validateCodeIntelUploadFlags(); err != nil {
+			return nil, nil, err
 		}
+
+		targets = append(targets, codeintelUploadTarget{
+			file:           codeintelUploadFlags.file,
+			root:           codeintelUploadFlags.root,
+			indexer:        codeintelUploadFlags.indexer,
+			indexerVersion: codeintelUploadFlags.indexerVersion,
+		})
 	}
 
-	if err := validateCodeIntelUploadFlags(); err != nil {
-		return nil, err
+	return out, targets, nil
+}
+
+// expandCodeIntelUploadFiles returns the index files given with -file, expanding
+// glob patterns. If no -file flag is given, the default index file is returned.
+func expandCodeIntelUploadFiles(patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		return []string{"./dump.lsif"}, nil
 	}
 
-	return out, nil
+	var files []string
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		matches := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") && !isStreamedCodeIntelUploadSource(pattern) {
+			var err error
+			if matches, err = filepath.Glob(pattern); err != nil {
+				return nil, errors.Wrapf(err, "invalid -file pattern %q", pattern)
+			}
+			if len(matches) == 0 {
+				return nil, errors.Newf("no index files match -file pattern %q", pattern)
+			}
+		}
+		for _, file := range matches {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
 }
 
 // codeintelUploadOutput returns an output object that should be used to print the progres
@@ -146,7 +243,7 @@ func codeintelUploadOutput() (out *output.Output) {
 		return nil
 	}
 
-	return output.NewOutput(flag.CommandLine.Output(), output.OutputOpts{
+	return output.NewOutput(statusWriter(flag.CommandLine.Output()), output.OutputOpts{
 		Verbose: true,
 	})
 }
@@ -321,6 +418,11 @@ func isFlagSet(fs *flag.FlagSet, name string) (found bool) {
 //
 // Note: This function must not be called before codeintelUploadFlagset.Parse.
 func inferIndexRoot() (string, error) {
+	if codeintelUploadFlags.source != "" {
+		// An index read from standard input or a URL has no location in the
+		// repository, so it is uploaded for the repository root.
+		return "", nil
+	}
 	return codeintel.InferRoot(codeintelUploadFlags.file)
 }
 
@@ -348,6 +450,17 @@ func validateCodeIntelUploadFlags() error {
 		return errors.New("root must not be outside of repository")
 	}
 
+	if codeintel.IsCommitHash(codeintelUploadFlags.commit) {
+		// Sourcegraph stores commits as lowercase hashes.
+		codeintelUploadFlags.commit = strings.ToLower(codeintelUploadFlags.commit)
+	} else {
+		commit, err := codeintel.ResolveCommit(codeintelUploadFlags.commit)
+		if err != nil {
+			return errors.Newf("commit %q must be a 40-character hash or a ref that can be resolved in the local clone", codeintelUploadFlags.commit)
+		}
+		codeintelUploadFlags.commit = commit
+	}
+
 	if codeintelUploadFlags.maxPayloadSizeMb < 25 {
 		return errors.New("max-payload-size must be at least 25 (MB)")
 	}
@@ -96,3 +96,37 @@ func TestReplaceBaseName(t *testing.T) {
 	require.Equal(t, filepath.Join("a", "d.e"),
 		replaceBaseName(filepath.Join("a", "b.c"), "d.e"))
 }
+
+func TestExpandCodeIntelUploadFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"go.scip", "web.scip", "dump.lsif"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("default", func(t *testing.T) {
+		files, err := expandCodeIntelUploadFiles(nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"./dump.lsif"}, files)
+	})
+
+	t.Run("globs and duplicates", func(t *testing.T) {
+		files, err := expandCodeIntelUploadFiles([]string{
+			filepath.Join(dir, "*.scip"),
+			filepath.Join(dir, "go.scip"),
+			filepath.Join(dir, "dump.lsif"),
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			filepath.Join(dir, "go.scip"),
+			filepath.Join(dir, "web.scip"),
+			filepath.Join(dir, "dump.lsif"),
+		}, files)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		_, err := expandCodeIntelUploadFiles([]string{filepath.Join(dir, "*.lsif-typed")})
+		require.Error(t, err)
+	})
+}
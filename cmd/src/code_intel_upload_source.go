@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// isStreamedCodeIntelUploadSource returns true if the -file value names an index
+// that is read from standard input ("-") or downloaded from an HTTP(S) URL,
+// rather than a local file.
+func isStreamedCodeIntelUploadSource(file string) bool {
+	return file == "-" || strings.HasPrefix(file, "http://") || strings.HasPrefix(file, "https://")
+}
+
+// codeintelUploadSource is an index read from standard input or downloaded from
+// a URL.
+type codeintelUploadSource struct {
+	r    io.Reader
+	body io.Closer
+	// name is index.scip or dump.lsif based on the contents of the index, unless
+	// the URL names a file with a SCIP or LSIF extension.
+	name string
+	// size is the size of the index reported by the server, or -1 if unknown.
+	size int64
+}
+
+// openCodeIntelUploadSource starts reading the index from standard input if
+// source is "-", or downloading it from the URL source otherwise.
+func openCodeIntelUploadSource(ctx context.Context, source string) (*codeintelUploadSource, error) {
+	s := &codeintelUploadSource{size: -1}
+	r := io.Reader(os.Stdin)
+	if source != "-" {
+		u, err := url.Parse(source)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid -file URL")
+		}
+		switch ext := path.Ext(u.Path); ext {
+		case ".scip", ".lsif", ".lsif-typed":
+			s.name = path.Base(u.Path)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, errors.Wrap(err, "downloading index")
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, errors.Newf("downloading index: unexpected status %s", resp.Status)
+		}
+		r, s.body, s.size = resp.Body, resp.Body, resp.ContentLength
+	}
+
+	br := bufio.NewReader(r)
+	if s.name == "" {
+		// LSIF indexes are JSON lines, whereas SCIP indexes are protobuf.
+		s.name = "index.scip"
+		if first, err := br.Peek(1); err == nil && first[0] == '{' {
+			s.name = "dump.lsif"
+		}
+	}
+	s.r = br
+	return s, nil
+}
+
+func (s *codeintelUploadSource) Close() error {
+	if s.body == nil {
+		return nil
+	}
+	return s.body.Close()
+}
+
+// spool writes the index to a file named after it in dir, and returns the path
+// of the file. If the server reported the size of the index, a download that
+// ends early is reported as an error.
+func (s *codeintelUploadSource) spool(dir string) (string, error) {
+	file := filepath.Join(dir, s.name)
+	f, err := os.Create(file)
+	if err != nil {
+		return "", err
+	}
+	written, err := io.Copy(f, s.r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "reading index")
+	}
+	if s.size >= 0 && written != s.size {
+		return "", errors.Newf("reading index: got %d of %d bytes", written, s.size)
+	}
+	if written == 0 {
+		return "", errors.New("reading index: the index is empty")
+	}
+	return file, nil
+}
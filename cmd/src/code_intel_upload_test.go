@@ -0,0 +1,147 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/src-cli/internal/api"
+)
+
+func TestCheckCodeIntelUploadCommit(t *testing.T) {
+	for name, tc := range map[string]struct {
+		response string
+		wantErr  string
+	}{
+		"known commit":   {response: `{"data": {"repository": {"commit": {"oid": "deadbeef"}}}}`},
+		"unknown commit": {response: `{"data": {"repository": {"commit": null}}}`, wantErr: "Push the commit"},
+		"unknown repo":   {response: `{"data": {"repository": null}}`, wantErr: "not found"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tc.response))
+			}))
+			t.Cleanup(ts.Close)
+
+			client := api.NewClient(api.ClientOpts{Endpoint: ts.URL, Out: io.Discard})
+			err := checkCodeIntelUploadCommit(context.Background(), client, "github.com/sourcegraph/src-cli", "deadbeef")
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHandleCodeIntelUploadCommitCheck(t *testing.T) {
+	var gotPath, gotAuth, gotCommit string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables struct {
+				Commit string `json:"commit"`
+			} `json:"variables"`
+		}
+		if zr, err := gzip.NewReader(r.Body); err == nil {
+			_ = json.NewDecoder(zr).Decode(&body)
+		}
+		gotPath, gotAuth, gotCommit = r.URL.Path, r.Header.Get("Authorization"), body.Variables.Commit
+		w.Write([]byte(`{"data": {"repository": {"commit": null}}}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	oldCfg := cfg
+	cfg = &config{Endpoint: ts.URL, AccessToken: "t"}
+	t.Cleanup(func() { cfg = oldCfg })
+
+	file := filepath.Join(t.TempDir(), "dump.lsif")
+	if err := os.WriteFile(file, []byte(`{"id":1,"type":"vertex","label":"metaData"}`+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := handleCodeIntelUpload([]string{
+		"-json",
+		"-file=" + file,
+		"-repo=github.com/sourcegraph/src-cli",
+		"-commit=" + strings.Repeat("DEADBEEF", 5),
+		"-root=",
+		"-indexer=lsif-go",
+		"-indexerVersion=1.0",
+	})
+	if err == nil || !strings.Contains(err.Error(), "not known") {
+		t.Fatalf("expected unknown commit error, got %v", err)
+	}
+	if gotPath != "/.api/graphql" || gotAuth != "token t" {
+		t.Errorf("unexpected commit check request: path %q, authorization %q", gotPath, gotAuth)
+	}
+	if want := strings.Repeat("deadbeef", 5); gotCommit != want {
+		t.Errorf("unexpected commit: want %s, got %s", want, gotCommit)
+	}
+}
+
+func TestCodeIntelUploadSource(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/builds/1/index.scip", "/builds/1/artifact":
+			w.Write([]byte("\x0a\x02scip"))
+		case "/builds/2/artifact":
+			w.Write([]byte(`{"id":1,"type":"vertex","label":"metaData"}` + "\n"))
+		case "/builds/2/chunked":
+			// Flushing before the body is written leaves its size unknown.
+			w.(http.Flusher).Flush()
+			w.Write([]byte(`{"id":1,"type":"vertex","label":"metaData"}` + "\n"))
+		case "/builds/3/index.scip":
+			w.Header().Set("Content-Length", "100")
+			w.Write([]byte("truncated"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	for name, tc := range map[string]struct {
+		path     string
+		wantName string
+		wantErr  bool
+	}{
+		"named by URL":         {path: "/builds/1/index.scip", wantName: "index.scip"},
+		"SCIP by contents":     {path: "/builds/1/artifact", wantName: "index.scip"},
+		"LSIF by contents":     {path: "/builds/2/artifact", wantName: "dump.lsif"},
+		"LSIF of unknown size": {path: "/builds/2/chunked", wantName: "dump.lsif"},
+		"truncated":            {path: "/builds/3/index.scip", wantErr: true},
+		"not found":            {path: "/builds/4/index.scip", wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			src, err := openCodeIntelUploadSource(context.Background(), ts.URL+tc.path)
+			var file string
+			dir := t.TempDir()
+			if err == nil {
+				defer src.Close()
+				file, err = src.spool(dir)
+			}
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(dir, tc.wantName); file != want {
+				t.Errorf("unexpected file: want %s, got %s", want, file)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/src-cli/internal/cmderrors"
+)
+
+func init() {
+	usage := `'src completion' prints a shell completion script for src.
+
+Usage:
+
+	src completion bash|zsh|fish
+
+The script completes commands, subcommands, and their flags.
+
+Examples:
+
+  Load completions in the current bash or zsh session:
+
+    	$ source <(src completion bash)
+    	$ source <(src completion zsh)
+
+  Load completions for every fish session:
+
+    	$ src completion fish > ~/.config/fish/completions/src.fish
+`
+
+	flagSet := flag.NewFlagSet("completion", flag.ExitOnError)
+	handler := func(args []string) error {
+		if err := flagSet.Parse(args); err != nil {
+			return err
+		}
+		if flagSet.NArg() != 1 {
+			return cmderrors.Usage("expected exactly one argument: the shell")
+		}
+
+		root := completionTree()
+		switch shell := flagSet.Arg(0); shell {
+		case "bash":
+			writeBashCompletion(os.Stdout, root)
+		case "zsh":
+			writeZshCompletion(os.Stdout, root)
+		case "fish":
+			writeFishCompletion(os.Stdout, root)
+		default:
+			return cmderrors.Usagef("unsupported shell %q: expected bash, zsh, or fish", shell)
+		}
+		return nil
+	}
+
+	// Register the command.
+	commands = append(commands, &command{
+		flagSet: flagSet,
+		handler: handler,
+		usageFunc: func() {
+			fmt.Fprint(flag.CommandLine.Output(), usage)
+		},
+	})
+}
+
+// completionNode is a command in the tree of commands to complete.
+type completionNode struct {
+	// path is the fully qualified name of the command, e.g. "src repos list".
+	path     string
+	names    []string
+	flags    []string
+	children []*completionNode
+}
+
+// completionTree builds the tree of commands from the registered commands and
+// their subcommands.
+func completionTree() *completionNode {
+	var build func(path string, names []string, cmd *command) *completionNode
+	build = func(path string, names []string, cmd *command) *completionNode {
+		flags := completionFlags(cmd.flagSet)
+		node := &completionNode{path: path, names: names, flags: flags}
+		if cmd.subcommands != nil {
+			for _, sub := range *cmd.subcommands {
+				name := sub.flagSet.Name()
+				names := []string{name}
+				for _, alias := range sub.aliases {
+					// Some commands list their own name as an alias.
+					if alias != name {
+						names = append(names, alias)
+					}
+				}
+				node.children = append(node.children, build(path+" "+name, names, sub))
+			}
+		}
+		for _, arg := range cmd.args {
+			// Flags may follow the argument, so they are completed after it too.
+			node.children = append(node.children, &completionNode{path: path + " " + arg, names: []string{arg}, flags: flags})
+		}
+		if len(node.children) > 0 {
+			sort.Slice(node.children, func(i, j int) bool { return node.children[i].path < node.children[j].path })
+		}
+		return node
+	}
+	return build("src", []string{"src"}, &command{flagSet: flag.CommandLine, subcommands: &commands})
+}
+
+// completionFlags returns the documented flags of the flag set, prefixed with a
+// dash.
+func completionFlags(flagSet *flag.FlagSet) []string {
+	var flags []string
+	flagSet.VisitAll(func(f *flag.Flag) {
+		// Deprecated flags have no usage.
+		if f.Usage != "" {
+			flags = append(flags, "-"+f.Name)
+		}
+	})
+	return flags
+}
+
+// walk calls fn for the node and each of its descendants.
+func (n *completionNode) walk(fn func(*completionNode)) {
+	fn(n)
+	for _, child := range n.children {
+		child.walk(fn)
+	}
+}
+
+// childNames returns the names and aliases of the node's children.
+func (n *completionNode) childNames() []string {
+	var names []string
+	for _, child := range n.children {
+		names = append(names, child.names...)
+	}
+	return names
+}
+
+func writeBashCompletion(w io.Writer, root *completionNode) {
+	fmt.Fprintln(w, "# bash completion for src")
+	fmt.Fprintln(w, "_src() {")
+	fmt.Fprintln(w, `	local cur="${COMP_WORDS[COMP_CWORD]}" path="src" word i words flags`)
+	fmt.Fprintln(w, "	for ((i = 1; i < COMP_CWORD; i++)); do")
+	fmt.Fprintln(w, `		word="${COMP_WORDS[i]}"`)
+	fmt.Fprintln(w, `		[[ $word == -* ]] && continue`)
+	fmt.Fprintln(w, `		case "$path $word" in`)
+	root.walk(func(n *completionNode) {
+		for _, child := range n.children {
+			for _, name := range child.names {
+				fmt.Fprintf(w, "		%q) path=%q ;;\n", n.path+" "+name, child.path)
+			}
+		}
+	})
+	fmt.Fprintln(w, "		esac")
+	fmt.Fprintln(w, "	done")
+	fmt.Fprintln(w, `	case "$path" in`)
+	root.walk(func(n *completionNode) {
+		fmt.Fprintf(w, "	%q) words=%q flags=%q ;;\n", n.path, strings.Join(n.childNames(), " "), strings.Join(n.flags, " "))
+	})
+	fmt.Fprintln(w, "	esac")
+	fmt.Fprintln(w, `	if [[ $cur == -* ]]; then`)
+	fmt.Fprintln(w, `		COMPREPLY=($(compgen -W "$flags" -- "$cur"))`)
+	fmt.Fprintln(w, "	else")
+	fmt.Fprintln(w, `		COMPREPLY=($(compgen -W "$words" -- "$cur"))`)
+	fmt.Fprintln(w, "	fi")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -o default -F _src src")
+}
+
+func writeZshCompletion(w io.Writer, root *completionNode) {
+	// zsh can run bash completion functions, which saves us from maintaining a
+	// second implementation of the command tree walk.
+	fmt.Fprintln(w, "#compdef src")
+	fmt.Fprintln(w, "autoload -U +X bashcompinit && bashcompinit")
+	writeBashCompletion(w, root)
+}
+
+func writeFishCompletion(w io.Writer, root *completionNode) {
+	fmt.Fprintln(w, "# fish completion for src")
+	fmt.Fprintln(w, "function __src_path")
+	fmt.Fprintln(w, "	set -l path src")
+	fmt.Fprintln(w, "	for word in (commandline -opc)[2..-1]")
+	fmt.Fprintln(w, `		switch "$path $word"`)
+	root.walk(func(n *completionNode) {
+		for _, child := range n.children {
+			for _, name := range child.names {
+				fmt.Fprintf(w, "			case %s\n", fishQuote(n.path+" "+name))
+				fmt.Fprintf(w, "				set path %s\n", fishQuote(child.path))
+			}
+		}
+	})
+	fmt.Fprintln(w, "		end")
+	fmt.Fprintln(w, "	end")
+	fmt.Fprintln(w, "	echo $path")
+	fmt.Fprintln(w, "end")
+	fmt.Fprintln(w)
+	root.walk(func(n *completionNode) {
+		condition := fishQuote(fmt.Sprintf("test (__src_path) = %s", fishQuote(n.path)))
+		if names := n.childNames(); len(names) > 0 {
+			fmt.Fprintf(w, "complete -c src -f -n %s -a %s\n", condition, fishQuote(strings.Join(names, " ")))
+		}
+		for _, f := range n.flags {
+			fmt.Fprintf(w, "complete -c src -n %s -o %s\n", condition, strings.TrimPrefix(f, "-"))
+		}
+	})
+}
+
+// fishQuote quotes s as a single-quoted fish string.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
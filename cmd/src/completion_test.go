@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCompletionTree(t *testing.T) {
+	root := completionTree()
+
+	find := func(path string) *completionNode {
+		var found *completionNode
+		root.walk(func(n *completionNode) {
+			if n.path == path {
+				found = n
+			}
+		})
+		if found == nil {
+			t.Fatalf("command %q not found", path)
+		}
+		return found
+	}
+
+	repos := find("src repos")
+	if names := strings.Join(repos.names, " "); names != "repos repo" {
+		t.Errorf("unexpected names %q", names)
+	}
+	if !contains(find("src search").childNames(), "saved") {
+		t.Error("src search saved is missing")
+	}
+	find("src search saved list")
+	find("src code-intel upload delete")
+	find("src lsif upload delete")
+	if !contains(find("src snapshot databases docker").flags, "-targets") {
+		t.Error("src snapshot databases docker -targets is missing")
+	}
+	if !contains(find("src repos list").flags, "-json") {
+		t.Error("src repos list -json is missing")
+	}
+	for _, f := range root.flags {
+		if f == "-endpoint" || f == "-config" {
+			t.Errorf("deprecated flag %s is completed", f)
+		}
+	}
+}
+
+func TestWriteFishCompletion(t *testing.T) {
+	root := &completionNode{
+		path:  "src",
+		names: []string{"src"},
+		children: []*completionNode{{
+			path:  "src repos",
+			names: []string{"repos", "repo"},
+			flags: []string{"-json"},
+		}},
+	}
+
+	var buf bytes.Buffer
+	writeFishCompletion(&buf, root)
+	for _, want := range []string{
+		"case 'src repo'\n\t\t\t\tset path 'src repos'",
+		`complete -c src -f -n 'test (__src_path) = \'src\'' -a 'repos repo'`,
+		`complete -c src -n 'test (__src_path) = \'src repos\'' -o json`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("script does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
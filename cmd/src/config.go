@@ -45,8 +45,9 @@ Use "src config [command] -h" for more information about a command.
 
 	// Register the command.
 	commands = append(commands, &command{
-		flagSet: flagSet,
-		handler: handler,
+		flagSet:     flagSet,
+		handler:     handler,
+		subcommands: &configCommands,
 		usageFunc: func() {
 			fmt.Println(usage)
 		},
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/sourcegraph/src-cli/internal/codeintel"
+)
+
+// configGitRemote maps the git remotes of repositories whose names start with a
+// prefix to a profile, e.g.
+//
+//	"gitRemotes": [
+//		{"remote": "github.com/acme/", "profile": "acme"},
+//		{"remote": "gitlab.example.com/", "profile": "internal"}
+//	]
+type configGitRemote struct {
+	// Remote is a prefix of the repository name derived from the git remote,
+	// such as "github.com/acme/". It is matched case-insensitively.
+	Remote  string `json:"remote"`
+	Profile string `json:"profile"`
+}
+
+// gitRemoteRepo returns the repository name derived from the origin remote of
+// the git clone enclosing the working directory. It is a variable so that tests
+// can replace it.
+var gitRemoteRepo = codeintel.InferRepo
+
+// gitRemoteProfile returns the profile of the first of the config's GitRemotes
+// that matches the repository name, or an empty string if none does.
+func (c *config) gitRemoteProfile(repo string) string {
+	repo = strings.ToLower(repo)
+	for _, r := range c.GitRemotes {
+		if r.Remote != "" && strings.HasPrefix(repo, strings.ToLower(r.Remote)) {
+			return r.Profile
+		}
+	}
+	return ""
+}
@@ -38,9 +38,10 @@ Alias: "src ext"
 
 	// Register the command.
 	commands = append(commands, &command{
-		flagSet: flagSet,
-		aliases: []string{"ext", "extension"},
-		handler: handler,
+		flagSet:     flagSet,
+		aliases:     []string{"ext", "extension"},
+		handler:     handler,
+		subcommands: &extensionsCommands,
 		usageFunc: func() {
 			fmt.Println(usage)
 		},
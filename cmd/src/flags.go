@@ -0,0 +1,22 @@
+package main
+
+import "strings"
+
+// stringSliceFlag is a flag.Value that collects the values of a flag that is
+// given multiple times. Each distinct value is only collected once, since the
+// flag sets of commands are parsed both by commander.run and by their handler.
+type stringSliceFlag []string
+
+func (f *stringSliceFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringSliceFlag) Set(value string) error {
+	for _, v := range *f {
+		if v == value {
+			return nil
+		}
+	}
+	*f = append(*f, value)
+	return nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStringSliceFlag(t *testing.T) {
+	var values stringSliceFlag
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.Var(&values, "mount", "")
+
+	// Parse the flags twice, as commander.run and then the command's handler do.
+	args := []string{"-mount", "a:/a", "-mount", "b:/b"}
+	for i := 0; i < 2; i++ {
+		if err := flagSet.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff := cmp.Diff(stringSliceFlag{"a:/a", "b:/b"}, values); diff != "" {
+		t.Errorf("unexpected values (-want +got):\n%s", diff)
+	}
+}
@@ -83,6 +83,8 @@ func parseTemplate(text string) (*template.Template, error) {
 
 		// Register search-specific template functions
 		"searchSequentialLineNumber":        searchTemplateFuncs["searchSequentialLineNumber"],
+		"searchContextLines":                searchTemplateFuncs["searchContextLines"],
+		"searchRepoGroupHeader":             searchTemplateFuncs["searchRepoGroupHeader"],
 		"searchHighlightMatch":              searchTemplateFuncs["searchHighlightMatch"],
 		"searchHighlightPreview":            searchTemplateFuncs["searchHighlightPreview"],
 		"searchHighlightDiffPreview":        searchTemplateFuncs["searchHighlightDiffPreview"],
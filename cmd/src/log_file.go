@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/src-cli/internal/version"
+)
+
+// fileLogger writes to the file given with -log-file. It is nil if no log file
+// was requested.
+var fileLogger *log.Logger
+
+// openLogFile opens the file given with -log-file for appending and sets up
+// fileLogger. It is a no-op if no log file was requested, or if it has already
+// been opened by a parent command. The file is never closed: it is written to
+// until the process exits.
+//
+// The log is meant to be shared, e.g. in support bundles, so it must not
+// contain secrets. Only the names of flags are logged, never their values or
+// the positional arguments, which may hold passwords, tokens or headers.
+func openLogFile() error {
+	if *logFile == "" || fileLogger != nil {
+		return nil
+	}
+	f, err := os.OpenFile(*logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.Wrap(err, "opening log file")
+	}
+	fileLogger = log.New(f, "", log.LstdFlags|log.Lmicroseconds|log.LUTC)
+	fileLogger.Printf("start version=%s flags=%q endpoint=%s", version.BuildTag, flagNames(flag.CommandLine), cfg.Endpoint)
+	return nil
+}
+
+// logCommand logs the command about to be run, and the names of the flags set
+// for it, to the log file, if any.
+func logCommand(name string, flagSet *flag.FlagSet) {
+	if fileLogger == nil {
+		return
+	}
+	fileLogger.Printf("command name=%q flags=%q", name, flagNames(flagSet))
+}
+
+// flagNames returns the names of the flags that have been set in flagSet,
+// without their values.
+func flagNames(flagSet *flag.FlagSet) string {
+	var names []string
+	flagSet.Visit(func(f *flag.Flag) {
+		names = append(names, "-"+f.Name)
+	})
+	return strings.Join(names, " ")
+}
+
+// logCommandResult logs the outcome of the command started at start to the
+// log file, if any.
+func logCommandResult(start time.Time, err error) {
+	if fileLogger == nil {
+		return
+	}
+	if err != nil {
+		fileLogger.Printf("exit duration=%s error=%q", time.Since(start), err)
+		return
+	}
+	fileLogger.Printf("exit duration=%s", time.Since(start))
+}
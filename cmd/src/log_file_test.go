@@ -0,0 +1,22 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestFlagNames(t *testing.T) {
+	flagSet := flag.NewFlagSet("serve-git", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	flagSet.String("addr", "", "")
+	flagSet.String("password", "", "")
+	flagSet.Bool("v", false, "")
+	if err := flagSet.Parse([]string{"-password=hunter2", "-v", "/srv/repos"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := flagNames(flagSet), "-password -v"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
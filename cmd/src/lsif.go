@@ -20,9 +20,10 @@ Use 'src code-intel' instead.
 
 	// Register the command.
 	commands = append(commands, &command{
-		flagSet: flagSet,
-		aliases: []string{"lsif"},
-		handler: handler,
+		flagSet:     flagSet,
+		aliases:     []string{"lsif"},
+		handler:     handler,
+		subcommands: &lsifCommands,
 		usageFunc: func() {
 			fmt.Println(usage)
 		},
@@ -25,16 +25,29 @@ Usage:
 Environment variables
 	SRC_ACCESS_TOKEN  Sourcegraph access token
 	SRC_ENDPOINT      endpoint to use, if unset will default to "https://sourcegraph.com"
+	SRC_FLAGS         default flags, parsed before those on the command line; flags that
+	                  a command does not define are ignored (e.g. "-insecure-skip-verify -trace")
+
+Arguments of the form @file, where file exists, are replaced with the arguments
+read from the file, separated by whitespace; # begins a comment. Use @@ for a
+literal @.
 
 The options are:
 
 	-v                               print verbose output
+	-quiet                           suppress status output, printing only errors and the data commands produce
+	-profile=name                    use the endpoint and access token of the named profile in the config file
+	-endpoint-from-git               use the profile that the config file's gitRemotes map the working directory's git remote to
+	-no-version-check                don't warn if the Sourcegraph instance's version is incompatible with src's
+	-log-file=path                   append a log of the command, its API requests and their timings to this file
+	-output-format=text|json         output format; json wraps results in a {"data": ..., "errors": [...]} envelope (supported by repos add-kvp, search and version)
 
 The commands are:
 
 	api             interacts with the Sourcegraph GraphQL API
 	batch           manages batch changes
 	code-intel      manages code intelligence data
+	completion      prints a shell completion script for bash, zsh, or fish
 	config          manages global, org, and user settings
 	extensions,ext  manages extensions (experimental)
 	extsvc          manages external services
@@ -54,6 +67,14 @@ Use "src [command] -h" for more information about a command.
 
 var (
 	verbose = flag.Bool("v", false, "print verbose output")
+	quiet   = flag.Bool("quiet", false, "suppress status output, printing only errors and the data commands produce")
+	profile = flag.String("profile", "", "use the endpoint and access token of the named profile in the config file")
+
+	endpointFromGit = flag.Bool("endpoint-from-git", false, "use the profile that the config file's gitRemotes map the working directory's git remote to")
+
+	outputFormat   = flag.String("output-format", outputFormatText, "output format: text or json")
+	noVersionCheck = flag.Bool("no-version-check", false, "don't warn if the Sourcegraph instance's version is incompatible with src's")
+	logFile        = flag.String("log-file", "", "append a log of the command, its API requests and their timings to this file")
 
 	// The following arguments are deprecated which is why they are no longer documented
 	configPath = flag.String("config", "", "")
@@ -68,7 +89,11 @@ func main() {
 	log.SetFlags(0)
 	log.SetPrefix("")
 
-	commands.run(flag.CommandLine, "src", usageText, os.Args[1:])
+	args, err := expandArgsFiles(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	commands.run(flag.CommandLine, "src", usageText, args)
 }
 
 var cfg *config
@@ -79,9 +104,45 @@ type config struct {
 	AccessToken       string            `json:"accessToken"`
 	AdditionalHeaders map[string]string `json:"additionalHeaders"`
 
+	// Profiles are named endpoint and access token pairs that can be
+	// selected with the -profile flag.
+	Profiles map[string]configProfile `json:"profiles,omitempty"`
+
+	// GitRemotes map git remotes to profiles for the -endpoint-from-git flag.
+	GitRemotes []configGitRemote `json:"gitRemotes,omitempty"`
+
+	// SavedSearches maps endpoints to the named search queries saved for
+	// that endpoint with 'src search saved add'.
+	SavedSearches map[string]map[string]string `json:"savedSearches,omitempty"`
+
+	// SearchDefaults are applied to 'src search' queries unless -no-defaults
+	// is given.
+	SearchDefaults *searchDefaults `json:"searchDefaults,omitempty"`
+
 	ConfigFilePath string
 }
 
+// configProfile is a named endpoint and access token pair.
+type configProfile struct {
+	Endpoint    string `json:"endpoint"`
+	AccessToken string `json:"accessToken"`
+}
+
+// applyProfile replaces the endpoint and access token with those of the named
+// profile.
+func (c *config) applyProfile(name string) error {
+	p, ok := c.Profiles[name]
+	if !ok {
+		return errors.Newf("profile %q not found in config file", name)
+	}
+	if p.Endpoint == "" {
+		return errors.Newf("profile %q has no endpoint", name)
+	}
+	c.Endpoint = p.Endpoint
+	c.AccessToken = p.AccessToken
+	return nil
+}
+
 // apiClient returns an api.Client built from the configuration.
 func (c *config) apiClient(flags *api.Flags, out io.Writer) api.Client {
 	return api.NewClient(api.ClientOpts{
@@ -90,15 +151,19 @@ func (c *config) apiClient(flags *api.Flags, out io.Writer) api.Client {
 		AdditionalHeaders: c.AdditionalHeaders,
 		Flags:             flags,
 		Out:               out,
+		Verbose:           *verbose,
+		Logger:            fileLogger,
+		VersionCheck:      !*noVersionCheck,
 	})
 }
 
 var testHomeDir string // used by tests to mock the user's $HOME
 
-// readConfig reads the config file from the given path.
-func readConfig() (*config, error) {
-	cfgPath := *configPath
-	userSpecified := *configPath != ""
+// configFilePath returns the path to the config file, and whether it was
+// explicitly specified by the user with the -config flag.
+func configFilePath() (cfgPath string, userSpecified bool, err error) {
+	cfgPath = *configPath
+	userSpecified = *configPath != ""
 
 	var homeDir string
 	if testHomeDir != "" {
@@ -106,7 +171,7 @@ func readConfig() (*config, error) {
 	} else {
 		u, err := user.Current()
 		if err != nil {
-			return nil, err
+			return "", false, err
 		}
 		homeDir = u.HomeDir
 	}
@@ -116,6 +181,15 @@ func readConfig() (*config, error) {
 	} else if strings.HasPrefix(cfgPath, "~/") {
 		cfgPath = filepath.Join(homeDir, cfgPath[2:])
 	}
+	return cfgPath, userSpecified, nil
+}
+
+// readConfig reads the config file from the given path.
+func readConfig() (*config, error) {
+	cfgPath, userSpecified, err := configFilePath()
+	if err != nil {
+		return nil, err
+	}
 	data, err := os.ReadFile(os.ExpandEnv(cfgPath))
 	if err != nil && (!os.IsNotExist(err) || userSpecified) {
 		return nil, err
@@ -128,12 +202,25 @@ func readConfig() (*config, error) {
 		}
 	}
 
+	fromGit := endpointFromGit != nil && *endpointFromGit
+
+	// Apply the selected profile, if any.
+	if profile != nil && *profile != "" {
+		if fromGit {
+			return nil, errors.New("-profile and -endpoint-from-git cannot be combined")
+		}
+		if err := cfg.applyProfile(*profile); err != nil {
+			return nil, err
+		}
+	}
+
 	envToken := os.Getenv("SRC_ACCESS_TOKEN")
 	envEndpoint := os.Getenv("SRC_ENDPOINT")
 
-	if userSpecified {
-		// If a config file is present, either zero or both environment variables must be present.
-		// We don't want to partially apply environment variables.
+	if userSpecified || (profile != nil && *profile != "") {
+		// If a config file or profile is selected, either zero or both environment
+		// variables must be present. We don't want to partially apply environment
+		// variables, which could send the token of one instance to another.
 		if envToken == "" && envEndpoint != "" {
 			return nil, errConfigMerge
 		}
@@ -149,6 +236,19 @@ func readConfig() (*config, error) {
 	if envEndpoint != "" {
 		cfg.Endpoint = envEndpoint
 	}
+	// The profile mapped to the git remote takes precedence over the
+	// environment, as it was explicitly asked for. If there is no git remote,
+	// or no mapping matches it, the endpoint is resolved as usual.
+	if fromGit {
+		if repo, err := gitRemoteRepo(); err == nil {
+			if name := cfg.gitRemoteProfile(repo); name != "" {
+				if err := cfg.applyProfile(name); err != nil {
+					return nil, errors.Wrapf(err, "git remote %s", repo)
+				}
+			}
+		}
+	}
+
 	if cfg.Endpoint == "" {
 		cfg.Endpoint = "https://sourcegraph.com"
 	}
@@ -165,6 +265,39 @@ func readConfig() (*config, error) {
 	return &cfg, nil
 }
 
+// updateConfigFile sets the given top-level key in the config file to value,
+// creating the file if necessary. Other keys in the file are preserved as-is.
+func updateConfigFile(key string, value interface{}) error {
+	cfgPath, _, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	cfgPath = os.ExpandEnv(cfgPath)
+
+	contents := map[string]json.RawMessage{}
+	data, err := os.ReadFile(cfgPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &contents); err != nil {
+			return errors.Wrapf(err, "parsing config file %q", cfgPath)
+		}
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	contents[key] = raw
+
+	data, err = json.MarshalIndent(contents, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cfgPath, append(data, '\n'), 0600)
+}
+
 func cleanEndpoint(urlStr string) string {
 	return strings.TrimSuffix(urlStr, "/")
 }
@@ -7,6 +7,8 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
 func TestReadConfig(t *testing.T) {
@@ -18,8 +20,16 @@ func TestReadConfig(t *testing.T) {
 		envHeaders   string
 		envEndpoint  string
 		flagEndpoint string
-		want         *config
-		wantErr      string
+		flagProfile  string
+		// fileInHome writes the config file to its default location in the home
+		// directory, rather than passing it with -config.
+		fileInHome bool
+		// flagEndpointFromGit sets -endpoint-from-git, with gitRemote as the
+		// repository name derived from the git remote.
+		flagEndpointFromGit bool
+		gitRemote           string
+		want                *config
+		wantErr             string
 	}{
 		{
 			name: "defaults",
@@ -151,6 +161,140 @@ func TestReadConfig(t *testing.T) {
 				AdditionalHeaders: map[string]string{"foo-bar": "bar-baz", "foo": "bar"},
 			},
 		},
+		{
+			name:        "profile from config file",
+			flagProfile: "prod",
+			fileContents: &config{
+				Endpoint:    "https://example.com/",
+				AccessToken: "deadbeef",
+				Profiles: map[string]configProfile{
+					"prod": {Endpoint: "https://prod.example.com/", AccessToken: "prodtoken"},
+				},
+			},
+			want: &config{
+				Endpoint:          "https://prod.example.com",
+				AccessToken:       "prodtoken",
+				AdditionalHeaders: map[string]string{},
+				Profiles: map[string]configProfile{
+					"prod": {Endpoint: "https://prod.example.com/", AccessToken: "prodtoken"},
+				},
+			},
+		},
+		{
+			name:        "environment should override profile",
+			flagProfile: "prod",
+			envEndpoint: "https://override.com",
+			envToken:    "abc",
+			fileContents: &config{
+				Profiles: map[string]configProfile{
+					"prod": {Endpoint: "https://prod.example.com/", AccessToken: "prodtoken"},
+				},
+			},
+			want: &config{
+				Endpoint:          "https://override.com",
+				AccessToken:       "abc",
+				AdditionalHeaders: map[string]string{},
+				Profiles: map[string]configProfile{
+					"prod": {Endpoint: "https://prod.example.com/", AccessToken: "prodtoken"},
+				},
+			},
+		},
+		{
+			name:        "profile with partial environment override",
+			flagProfile: "prod",
+			fileInHome:  true,
+			envToken:    "abc",
+			fileContents: &config{
+				Profiles: map[string]configProfile{
+					"prod": {Endpoint: "https://prod.example.com/", AccessToken: "prodtoken"},
+				},
+			},
+			wantErr: errConfigMerge.Error(),
+		},
+		{
+			name:        "missing profile",
+			flagProfile: "staging",
+			fileContents: &config{
+				Profiles: map[string]configProfile{
+					"prod": {Endpoint: "https://prod.example.com/", AccessToken: "prodtoken"},
+				},
+			},
+			wantErr: `profile "staging" not found in config file`,
+		},
+		{
+			name:                "endpoint from git remote should override environment",
+			flagEndpointFromGit: true,
+			gitRemote:           "github.com/acme/app",
+			envEndpoint:         "https://override.com",
+			envToken:            "abc",
+			fileContents: &config{
+				Profiles: map[string]configProfile{
+					"acme": {Endpoint: "https://acme.example.com/", AccessToken: "acmetoken"},
+				},
+				GitRemotes: []configGitRemote{{Remote: "github.com/Acme/", Profile: "acme"}},
+			},
+			want: &config{
+				Endpoint:          "https://acme.example.com",
+				AccessToken:       "acmetoken",
+				AdditionalHeaders: map[string]string{},
+				Profiles: map[string]configProfile{
+					"acme": {Endpoint: "https://acme.example.com/", AccessToken: "acmetoken"},
+				},
+				GitRemotes: []configGitRemote{{Remote: "github.com/Acme/", Profile: "acme"}},
+			},
+		},
+		{
+			name:                "endpoint from git remote without match",
+			flagEndpointFromGit: true,
+			gitRemote:           "github.com/other/app",
+			fileContents: &config{
+				Endpoint: "https://example.com/",
+				Profiles: map[string]configProfile{
+					"acme": {Endpoint: "https://acme.example.com/", AccessToken: "acmetoken"},
+				},
+				GitRemotes: []configGitRemote{{Remote: "github.com/Acme/", Profile: "acme"}},
+			},
+			want: &config{
+				Endpoint:          "https://example.com",
+				AdditionalHeaders: map[string]string{},
+				Profiles: map[string]configProfile{
+					"acme": {Endpoint: "https://acme.example.com/", AccessToken: "acmetoken"},
+				},
+				GitRemotes: []configGitRemote{{Remote: "github.com/Acme/", Profile: "acme"}},
+			},
+		},
+		{
+			name:                "endpoint from git outside a git clone",
+			flagEndpointFromGit: true,
+			fileContents: &config{
+				Endpoint: "https://example.com/",
+				Profiles: map[string]configProfile{
+					"acme": {Endpoint: "https://acme.example.com/", AccessToken: "acmetoken"},
+				},
+				GitRemotes: []configGitRemote{{Remote: "github.com/Acme/", Profile: "acme"}},
+			},
+			want: &config{
+				Endpoint:          "https://example.com",
+				AdditionalHeaders: map[string]string{},
+				Profiles: map[string]configProfile{
+					"acme": {Endpoint: "https://acme.example.com/", AccessToken: "acmetoken"},
+				},
+				GitRemotes: []configGitRemote{{Remote: "github.com/Acme/", Profile: "acme"}},
+			},
+		},
+		{
+			name:                "endpoint from git with profile",
+			flagEndpointFromGit: true,
+			flagProfile:         "acme",
+			gitRemote:           "github.com/acme/app",
+			fileContents: &config{
+				Profiles: map[string]configProfile{
+					"acme": {Endpoint: "https://acme.example.com/", AccessToken: "acmetoken"},
+				},
+				GitRemotes: []configGitRemote{{Remote: "github.com/Acme/", Profile: "acme"}},
+			},
+			wantErr: "-profile and -endpoint-from-git cannot be combined",
+		},
 	}
 
 	for _, test := range tests {
@@ -174,6 +318,22 @@ func TestReadConfig(t *testing.T) {
 				t.Cleanup(func() { endpoint = nil })
 			}
 
+			if test.flagProfile != "" {
+				val := test.flagProfile
+				profile = &val
+				t.Cleanup(func() { profile = nil })
+			}
+
+			oldEndpointFromGit, oldGitRemoteRepo := endpointFromGit, gitRemoteRepo
+			t.Cleanup(func() { endpointFromGit, gitRemoteRepo = oldEndpointFromGit, oldGitRemoteRepo })
+			endpointFromGit = &test.flagEndpointFromGit
+			gitRemoteRepo = func() (string, error) {
+				if test.gitRemote == "" {
+					return "", errors.New("not a git repository")
+				}
+				return test.gitRemote, nil
+			}
+
 			if test.fileContents != nil {
 				oldConfigPath := *configPath
 				t.Cleanup(func() { *configPath = oldConfigPath })
@@ -183,11 +343,16 @@ func TestReadConfig(t *testing.T) {
 					t.Fatal(err)
 				}
 				filePath := filepath.Join(tmpDir, "config.json")
+				if test.fileInHome {
+					filePath = filepath.Join(tmpDir, "src-config.json")
+				}
 				err = os.WriteFile(filePath, data, 0600)
 				if err != nil {
 					t.Fatal(err)
 				}
-				*configPath = filePath
+				if !test.fileInHome {
+					*configPath = filePath
+				}
 			}
 
 			if err := os.Setenv("SRC_HEADER_FOO", test.envFooHeader); err != nil {
@@ -33,9 +33,10 @@ Use "src orgs [command] -h" for more information about a command.
 
 	// Register the command.
 	commands = append(commands, &command{
-		flagSet: flagSet,
-		aliases: []string{"org"},
-		handler: handler,
+		flagSet:     flagSet,
+		aliases:     []string{"org"},
+		handler:     handler,
+		subcommands: &orgsCommands,
 		usageFunc: func() {
 			fmt.Println(usage)
 		},
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/sourcegraph/src-cli/internal/api"
+	"github.com/sourcegraph/src-cli/internal/cmderrors"
 )
 
 func init() {
@@ -16,6 +17,11 @@ Examples:
 
     	$ src orgs create -name=abc-org -display-name='ABC Organization'
 
+  Create an organization and add a member to it:
+
+    	$ ORGID=$(src orgs create -name=abc-org -f='{{.ID}}')
+    	$ src orgs members add -org-id=$ORGID -username=alice
+
 `
 
 	flagSet := flag.NewFlagSet("create", flag.ExitOnError)
@@ -27,6 +33,7 @@ Examples:
 	var (
 		nameFlag        = flagSet.String("name", "", `The new organization's name. (required)`)
 		displayNameFlag = flagSet.String("display-name", "", `The new organization's display name. Defaults to organization name if unspecified.`)
+		formatFlag      = flagSet.String("f", "Organization {{.Name|json}} created with ID {{.ID|json}}.", `Format for the output, using the syntax of Go package text/template. (e.g. "{{.ID}}")`)
 		apiFlags        = api.NewFlags(flagSet)
 	)
 
@@ -34,6 +41,18 @@ Examples:
 		if err := flagSet.Parse(args); err != nil {
 			return err
 		}
+		if *nameFlag == "" {
+			return cmderrors.Usage("-name must be specified")
+		}
+		displayName := *displayNameFlag
+		if displayName == "" {
+			displayName = *nameFlag
+		}
+
+		tmpl, err := parseTemplate(*formatFlag)
+		if err != nil {
+			return err
+		}
 
 		client := cfg.apiClient(apiFlags, flagSet.Output())
 
@@ -45,22 +64,21 @@ Examples:
     name: $name,
     displayName: $displayName,
   ) {
-    id
+    ...OrgFields
   }
-}`
+}` + orgFragment
 
 		var result struct {
-			CreateOrg Org
+			CreateOrganization Org
 		}
 		if ok, err := client.NewRequest(query, map[string]interface{}{
 			"name":        *nameFlag,
-			"displayName": *displayNameFlag,
+			"displayName": displayName,
 		}).Do(context.Background(), &result); err != nil || !ok {
 			return err
 		}
 
-		fmt.Printf("Organization %q created.\n", *nameFlag)
-		return nil
+		return execTemplate(tmpl, result.CreateOrganization)
 	}
 
 	// Register the command.
@@ -6,13 +6,14 @@ import (
 	"fmt"
 
 	"github.com/sourcegraph/src-cli/internal/api"
+	"github.com/sourcegraph/src-cli/internal/cmderrors"
 )
 
 func init() {
 	usage := `
 Examples:
 
-  Delete an organization by ID:
+  Delete an organization by ID, after confirmation:
 
     	$ src orgs delete -id=VXNlcjox
 
@@ -20,9 +21,9 @@ Examples:
 
     	$ src orgs delete -id=$(src orgs get -f='{{.ID}}' -name=abc-org)
 
-  Delete all organizations that match the query
+  Delete all organizations that match the query, without confirmation:
 
-    	$ src orgs list -f='{{.ID}}' -query=abc-org | xargs -n 1 -I ORGID src orgs delete -id=ORGID
+    	$ src orgs list -f='{{.ID}}' -query=abc-org | xargs -n 1 -I ORGID src orgs delete -y -id=ORGID
 
 `
 
@@ -34,13 +35,29 @@ Examples:
 	}
 	var (
 		orgIDFlag = flagSet.String("id", "", `The ID of the organization to delete.`)
+		yesFlag   = flagSet.Bool("y", false, "Skip the confirmation prompt.")
 		apiFlags  = api.NewFlags(flagSet)
 	)
+	flagSet.StringVar(orgIDFlag, "org", "", "Alias for -id.")
 
 	handler := func(args []string) error {
 		if err := flagSet.Parse(args); err != nil {
 			return err
 		}
+		if *orgIDFlag == "" {
+			return cmderrors.Usage("-id must be specified")
+		}
+
+		if !*yesFlag {
+			confirmed, err := verify(fmt.Sprintf("Do you wish to delete the organization with ID %q from %s", *orgIDFlag, cfg.Endpoint))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				fmt.Println("Aborting deletion.")
+				return nil
+			}
+		}
 
 		client := cfg.apiClient(apiFlags, flagSet.Output())
 
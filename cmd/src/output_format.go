@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sourcegraph/src-cli/internal/cmderrors"
+)
+
+const (
+	outputFormatText = "text"
+	outputFormatJSON = "json"
+)
+
+// validateOutputFormat checks the value of the global -output-format flag.
+func validateOutputFormat() error {
+	switch *outputFormat {
+	case outputFormatText, outputFormatJSON:
+		return nil
+	default:
+		return cmderrors.Usagef("invalid -output-format %q: must be %q or %q", *outputFormat, outputFormatText, outputFormatJSON)
+	}
+}
+
+// jsonOutput reports whether machine-readable output was requested with
+// -output-format=json.
+func jsonOutput() bool {
+	return outputFormat != nil && *outputFormat == outputFormatJSON
+}
+
+// outputEnvelope is the document written by commands when -output-format=json
+// is set. Data is the command specific result, and is null if the command
+// failed.
+type outputEnvelope struct {
+	Data   interface{}   `json:"data"`
+	Errors []outputError `json:"errors"`
+}
+
+type outputError struct {
+	Message string `json:"message"`
+}
+
+// writeOutputEnvelope writes data and errs to w as an indented JSON envelope.
+func writeOutputEnvelope(w io.Writer, data interface{}, errs ...error) error {
+	envelope := outputEnvelope{Data: data, Errors: []outputError{}}
+	for _, err := range errs {
+		envelope.Errors = append(envelope.Errors, outputError{Message: err.Error()})
+	}
+	b, err := marshalIndent(envelope)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// statusWriter returns the writer that status output, such as progress and
+// success lines, should be written to: w, or io.Discard if -quiet is set. Errors
+// are returned by commands and always printed, and data such as -json output is
+// not status output.
+func statusWriter(w io.Writer) io.Writer {
+	if *quiet {
+		return io.Discard
+	}
+	return w
+}
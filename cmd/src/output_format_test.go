@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+func TestWriteOutputEnvelope(t *testing.T) {
+	for name, tc := range map[string]struct {
+		data interface{}
+		errs []error
+		want map[string]interface{}
+	}{
+		"data": {
+			data: map[string]string{"key": "value"},
+			want: map[string]interface{}{
+				"data":   map[string]interface{}{"key": "value"},
+				"errors": []interface{}{},
+			},
+		},
+		"errors": {
+			errs: []error{errors.New("boom")},
+			want: map[string]interface{}{
+				"data":   nil,
+				"errors": []interface{}{map[string]interface{}{"message": "boom"}},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeOutputEnvelope(&buf, tc.data, tc.errs...); err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected envelope (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	old := *outputFormat
+	t.Cleanup(func() { *outputFormat = old })
+
+	for format, wantErr := range map[string]bool{
+		"text": false,
+		"json": false,
+		"yaml": true,
+		"":     true,
+	} {
+		*outputFormat = format
+		if err := validateOutputFormat(); (err != nil) != wantErr {
+			t.Errorf("format %q: unexpected error %v", format, err)
+		}
+	}
+}
+
+func TestStatusWriter(t *testing.T) {
+	old := *quiet
+	t.Cleanup(func() { *quiet = old })
+
+	var buf bytes.Buffer
+	*quiet = false
+	if w := statusWriter(&buf); w != &buf {
+		t.Errorf("unexpected writer %v", w)
+	}
+	*quiet = true
+	if w := statusWriter(&buf); w != io.Discard {
+		t.Errorf("unexpected writer %v with -quiet", w)
+	}
+}
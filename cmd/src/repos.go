@@ -26,6 +26,7 @@ The commands are:
 	add-kvp	   adds a key-value pair to a repository
 	update-kvp updates a key-value pair on a repository
 	delete-kvp deletes a key-value pair from a repository
+	reconcile  compares the repositories with a list from a code host
 
 Use "src repos [command] -h" for more information about a command.
 `
@@ -38,9 +39,10 @@ Use "src repos [command] -h" for more information about a command.
 
 	// Register the command.
 	commands = append(commands, &command{
-		flagSet: flagSet,
-		aliases: []string{"repo"},
-		handler: handler,
+		flagSet:     flagSet,
+		aliases:     []string{"repo"},
+		handler:     handler,
+		subcommands: &reposCommands,
 		usageFunc: func() {
 			fmt.Println(usage)
 		},
@@ -4,9 +4,12 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"os"
+	"strconv"
 
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 	"github.com/sourcegraph/src-cli/internal/api"
+	"github.com/sourcegraph/src-cli/internal/cmderrors"
 )
 
 func init() {
@@ -18,6 +21,36 @@ Examples:
     	$ src repos add-kvp -repo=repoID -key=mykey -value=myvalue
 
   Omitting -value will create a tag (a key with a null value).
+
+  Add a key-value pair with a value that must be an integer:
+
+    	$ src repos add-kvp -repo=repoID -key=team-size -value=12 -type=int
+
+  Values are always stored as strings. With -type=int or -type=bool, the value
+  is validated and stored in canonical form (e.g. "true" rather than "T").
+
+  Add a key-value pair, or update its value if the key already exists. This is
+  safe to run repeatedly:
+
+    	$ src repos add-kvp -repo=repoID -key=owner -value=search-team -upsert
+
+  Print the created key-value pair as JSON:
+
+    	$ src -output-format=json repos add-kvp -repo=repoID -key=mykey -value=myvalue
+
+  Add a key-value pair to all repositories whose names match a query, as listed
+  by 'src repos list -query':
+
+    	$ src repos add-kvp -query=github.com/myorg/ -key=owner -value=myorg -upsert
+
+  Adding to more than -confirm-above repositories asks for confirmation first.
+  Use -y to skip the confirmation, e.g. in scripts. With -output-format=json,
+  -y is required instead.
+
+  Print the repositories and the changes that would be made, without making
+  them:
+
+    	$ src repos add-kvp -query=github.com/myorg/ -key=owner -value=myorg -upsert -dry-run
 `
 
 	flagSet := flag.NewFlagSet("add-kvp", flag.ExitOnError)
@@ -27,19 +60,28 @@ Examples:
 		fmt.Println(usage)
 	}
 	var (
-		repoFlag  = flagSet.String("repo", "", `The ID of the repo to add the key-value pair to (required)`)
-		keyFlag   = flagSet.String("key", "", `The name of the key to add (required)`)
-		valueFlag = flagSet.String("value", "", `The value associated with the key. Defaults to null.`)
-		apiFlags  = api.NewFlags(flagSet)
+		repoFlag         = flagSet.String("repo", "", `The ID of the repo to add the key-value pair to (required, unless -query is set)`)
+		queryFlag        = flagSet.String("query", "", `Add the key-value pair to all repositories whose names match the query. (e.g. "github.com/myorg/")`)
+		keyFlag          = flagSet.String("key", "", `The name of the key to add (required)`)
+		valueFlag        = flagSet.String("value", "", `The value associated with the key. Defaults to null.`)
+		typeFlag         = flagSet.String("type", kvpTypeString, `The type of the value: "string", "int" or "bool". Non-string values are validated before they are added.`)
+		upsertFlag       = flagSet.Bool("upsert", false, `Update the value if the key already exists, instead of failing. Nothing is changed if the key already has the value.`)
+		dryRunFlag       = flagSet.Bool("dry-run", false, `Print the changes that would be made, without making them.`)
+		confirmAboveFlag = flagSet.Int("confirm-above", 10, `Ask for confirmation before adding the key-value pair to more than this many repositories matching -query.`)
+		yesFlag          = flagSet.Bool("y", false, `Skip the confirmation prompt.`)
+		apiFlags         = api.NewFlags(flagSet)
 	)
 
 	handler := func(args []string) error {
 		if err := flagSet.Parse(args); err != nil {
 			return err
 		}
-		if *repoFlag == "" {
+		if *repoFlag == "" && *queryFlag == "" {
 			return errors.New("error: repo is required")
 		}
+		if *repoFlag != "" && *queryFlag != "" {
+			return cmderrors.Usage("-repo and -query cannot be combined")
+		}
 
 		keyFlag = nil
 		valueFlag = nil
@@ -57,10 +99,125 @@ Examples:
 		if keyFlag == nil {
 			return errors.New("error: key is required")
 		}
+		if valueFlag != nil {
+			value, err := normalizeKVPValue(*typeFlag, *valueFlag)
+			if err != nil {
+				return err
+			}
+			valueFlag = &value
+		} else if *typeFlag != kvpTypeString {
+			return cmderrors.Usagef("-value is required with -type=%s", *typeFlag)
+		}
 
 		client := cfg.apiClient(apiFlags, flagSet.Output())
+		ctx := context.Background()
 
-		query := `mutation addKVP(
+		if *queryFlag == "" {
+			action, err := addKVP(ctx, client, *repoFlag, *keyFlag, valueFlag, *upsertFlag, *dryRunFlag)
+			if err != nil || action == "" {
+				return err
+			}
+			if jsonOutput() {
+				return writeOutputEnvelope(os.Stdout, map[string]interface{}{
+					"repo":   *repoFlag,
+					"key":    *keyFlag,
+					"value":  valueFlag,
+					"action": action,
+					"dryRun": *dryRunFlag,
+				})
+			}
+			if *dryRunFlag {
+				fmt.Printf("Key-value pair '%s' would be %s.\n", kvpString(*keyFlag, valueFlag), action)
+			} else {
+				fmt.Printf("Key-value pair '%s' %s.\n", kvpString(*keyFlag, valueFlag), action)
+			}
+			return nil
+		}
+
+		repos, err := listRepositories(ctx, client, listRepositoriesOpts{
+			Limit:      -1,
+			Query:      *queryFlag,
+			Cloned:     true,
+			NotCloned:  true,
+			Indexed:    true,
+			NotIndexed: true,
+		})
+		if err != nil || repos == nil {
+			return err
+		}
+		if len(repos) == 0 {
+			fmt.Fprintf(flagSet.Output(), "No repositories match %q.\n", *queryFlag)
+		}
+		if !*dryRunFlag && !*yesFlag && len(repos) > *confirmAboveFlag {
+			if jsonOutput() {
+				return cmderrors.Usagef("%d repositories match %q: -y is required to add the key-value pair to more than %d repositories with -output-format=json", len(repos), *queryFlag, *confirmAboveFlag)
+			}
+			for _, repo := range repos {
+				fmt.Println(repo.Name)
+			}
+			confirmed, err := verify(fmt.Sprintf("Do you wish to add the key-value pair '%s' to these %d repositories on %s", kvpString(*keyFlag, valueFlag), len(repos), cfg.Endpoint))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				fmt.Println("Aborting.")
+				return nil
+			}
+		}
+
+		var (
+			errs    errors.MultiError
+			results = []map[string]interface{}{}
+		)
+		for _, repo := range repos {
+			action, err := addKVP(ctx, client, repo.ID, *keyFlag, valueFlag, *upsertFlag, *dryRunFlag)
+			if err != nil {
+				errs = errors.Append(errs, errors.Wrapf(err, "Failed to add key-value pair to repository %q", repo.Name))
+				continue
+			}
+			if jsonOutput() {
+				results = append(results, map[string]interface{}{
+					"repo":   repo.ID,
+					"name":   repo.Name,
+					"key":    *keyFlag,
+					"value":  valueFlag,
+					"action": action,
+					"dryRun": *dryRunFlag,
+				})
+			} else if *dryRunFlag {
+				fmt.Printf("Key-value pair '%s' would be %s on %s.\n", kvpString(*keyFlag, valueFlag), action, repo.Name)
+			} else {
+				fmt.Printf("Key-value pair '%s' %s on %s.\n", kvpString(*keyFlag, valueFlag), action, repo.Name)
+			}
+		}
+		if jsonOutput() {
+			// Report the results and the errors in a single envelope, so that
+			// the commander doesn't write a second one for the errors.
+			var repoErrs []error
+			if errs != nil {
+				repoErrs = errs.Errors()
+			}
+			if err := writeOutputEnvelope(os.Stdout, results, repoErrs...); err != nil {
+				return err
+			}
+			if len(repoErrs) > 0 {
+				return cmderrors.ExitCode(1, nil)
+			}
+			return nil
+		}
+		return errs
+	}
+
+	// Register the command.
+	reposCommands = append(reposCommands, &command{
+		flagSet:      flagSet,
+		handler:      handler,
+		outputFormat: true,
+		usageFunc:    usageFunc,
+	})
+}
+
+const addKVPMutation = `mutation addKVP(
   $repo: ID!,
   $key: String!,
   $value: String,
@@ -74,26 +231,126 @@ Examples:
   }
 }`
 
-		if ok, err := client.NewRequest(query, map[string]interface{}{
-			"repo":  *repoFlag,
-			"key":   *keyFlag,
-			"value": valueFlag,
-		}).Do(context.Background(), nil); err != nil || !ok {
-			return err
+// The actions taken by 'src repos add-kvp' on a key-value pair.
+const (
+	kvpCreated   = "created"
+	kvpUpdated   = "updated"
+	kvpUnchanged = "unchanged"
+)
+
+// kvpString formats a key-value pair for display.
+func kvpString(key string, value *string) string {
+	if value == nil {
+		return key + ":<nil>"
+	}
+	return key + ":" + *value
+}
+
+// addKVP adds the key-value pair to the repository with the given ID, and
+// returns the action taken. With upsert, an existing key's value is updated
+// instead. With dryRun, the action that would be taken is returned without
+// making any change.
+//
+// An empty action and nil error are returned if no data was available, for
+// example because -get-curl was set.
+func addKVP(ctx context.Context, client api.Client, repoID, key string, value *string, upsert, dryRun bool) (string, error) {
+	action := kvpCreated
+	if upsert {
+		existing, err := fetchRepositoryKeyValuePairs(ctx, client, repoID)
+		if err != nil {
+			return "", err
 		}
+		action = kvpUpsertAction(existing, key, value)
+	}
+	if dryRun || action == kvpUnchanged {
+		return action, nil
+	}
 
-		if valueFlag != nil {
-			fmt.Printf("Key-value pair '%s:%v' created.\n", *keyFlag, *valueFlag)
-		} else {
-			fmt.Printf("Key-value pair '%s:<nil>' created.\n", *keyFlag)
+	mutation := addKVPMutation
+	if action == kvpUpdated {
+		mutation = updateKVPMutation
+	}
+	if ok, err := client.NewRequest(mutation, map[string]interface{}{
+		"repo":  repoID,
+		"key":   key,
+		"value": value,
+	}).Do(ctx, nil); err != nil || !ok {
+		return "", err
+	}
+	return action, nil
+}
+
+// kvpUpsertAction returns the action needed to set the key to value, given the
+// existing key-value pairs of the repository.
+func kvpUpsertAction(existing []KeyValuePair, key string, value *string) string {
+	for _, kvp := range existing {
+		if kvp.Key != key {
+			continue
 		}
-		return nil
+		if (kvp.Value == nil) == (value == nil) && (value == nil || *kvp.Value == *value) {
+			return kvpUnchanged
+		}
+		return kvpUpdated
 	}
+	return kvpCreated
+}
 
-	// Register the command.
-	reposCommands = append(reposCommands, &command{
-		flagSet:   flagSet,
-		handler:   handler,
-		usageFunc: usageFunc,
-	})
+const repositoryKeyValuePairsQuery = `query RepositoryKeyValuePairs($repo: ID!) {
+  node(id: $repo) {
+    ... on Repository {
+      keyValuePairs {
+        key
+        value
+      }
+    }
+  }
+}`
+
+// fetchRepositoryKeyValuePairs returns the key-value pairs of the repository
+// with the given ID.
+func fetchRepositoryKeyValuePairs(ctx context.Context, client api.Client, repoID string) ([]KeyValuePair, error) {
+	var result struct {
+		Node *struct {
+			KeyValuePairs []KeyValuePair
+		}
+	}
+	if ok, err := client.NewRequest(repositoryKeyValuePairsQuery, map[string]interface{}{
+		"repo": repoID,
+	}).Do(ctx, &result); err != nil || !ok {
+		return nil, err
+	}
+	if result.Node == nil {
+		return nil, errors.Newf("repository not found: %s", repoID)
+	}
+	return result.Node.KeyValuePairs, nil
+}
+
+const (
+	kvpTypeString = "string"
+	kvpTypeInt    = "int"
+	kvpTypeBool   = "bool"
+)
+
+// normalizeKVPValue validates that value is of the given type, and returns it
+// in canonical form. Key-value pairs only store strings, so typed values are
+// stored as their canonical string representation.
+func normalizeKVPValue(typ, value string) (string, error) {
+	switch typ {
+	case kvpTypeString:
+		return value, nil
+	case kvpTypeInt:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", errors.Newf("value %q is not an integer", value)
+		}
+		return strconv.FormatInt(n, 10), nil
+	case kvpTypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", errors.Newf("value %q is not a boolean", value)
+		}
+		return strconv.FormatBool(b), nil
+	default:
+		return "", cmderrors.Usagef("invalid -type %q: must be %q, %q or %q", typ, kvpTypeString, kvpTypeInt, kvpTypeBool)
+	}
 }
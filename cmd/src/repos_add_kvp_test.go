@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	mockapi "github.com/sourcegraph/src-cli/internal/api/mock"
+)
+
+func TestNormalizeKVPValue(t *testing.T) {
+	for _, tc := range []struct {
+		typ, value string
+		want       string
+		wantErr    bool
+	}{
+		{typ: "string", value: " anything ", want: " anything "},
+		{typ: "int", value: "12", want: "12"},
+		{typ: "int", value: "+007", want: "7"},
+		{typ: "int", value: "-3", want: "-3"},
+		{typ: "int", value: "1.5", wantErr: true},
+		{typ: "int", value: "twelve", wantErr: true},
+		{typ: "bool", value: "T", want: "true"},
+		{typ: "bool", value: "0", want: "false"},
+		{typ: "bool", value: "yes", wantErr: true},
+		{typ: "float", value: "1.5", wantErr: true},
+	} {
+		got, err := normalizeKVPValue(tc.typ, tc.value)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("normalizeKVPValue(%q, %q): unexpected error %v", tc.typ, tc.value, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("normalizeKVPValue(%q, %q): want %q, got %q", tc.typ, tc.value, tc.want, got)
+		}
+	}
+}
+
+func TestKVPUpsertAction(t *testing.T) {
+	value := func(s string) *string { return &s }
+	existing := []KeyValuePair{
+		{Key: "owner", Value: value("search")},
+		{Key: "tag"},
+	}
+	for _, tc := range []struct {
+		key   string
+		value *string
+		want  string
+	}{
+		{key: "team", value: value("search"), want: kvpCreated},
+		{key: "owner", value: value("search"), want: kvpUnchanged},
+		{key: "owner", value: value("batches"), want: kvpUpdated},
+		{key: "owner", want: kvpUpdated},
+		{key: "tag", want: kvpUnchanged},
+		{key: "tag", value: value(""), want: kvpUpdated},
+	} {
+		if got := kvpUpsertAction(existing, tc.key, tc.value); got != tc.want {
+			t.Errorf("kvpUpsertAction(%q): want %q, got %q", tc.key, tc.want, got)
+		}
+	}
+}
+
+func TestAddKVP(t *testing.T) {
+	ctx := context.Background()
+	value := "search"
+
+	existing := func(client *mockapi.Client) {
+		req := &mockapi.Request{Response: `{"node": {"keyValuePairs": [{"key": "owner", "value": "batches"}]}}`}
+		req.On("Do", mock.Anything, mock.Anything).Return(true, nil).Once()
+		client.On("NewRequest", repositoryKeyValuePairsQuery, map[string]interface{}{"repo": "repo1"}).Return(req).Once()
+	}
+	mutation := func(client *mockapi.Client, query string) {
+		req := &mockapi.Request{}
+		req.On("Do", mock.Anything, nil).Return(true, nil).Once()
+		client.On("NewRequest", query, map[string]interface{}{"repo": "repo1", "key": "owner", "value": &value}).Return(req).Once()
+	}
+
+	for _, tc := range []struct {
+		name           string
+		upsert, dryRun bool
+		setup          func(*mockapi.Client)
+		want           string
+	}{
+		{
+			name:  "add",
+			setup: func(c *mockapi.Client) { mutation(c, addKVPMutation) },
+			want:  kvpCreated,
+		},
+		{
+			name:   "upsert",
+			upsert: true,
+			setup: func(c *mockapi.Client) {
+				existing(c)
+				mutation(c, updateKVPMutation)
+			},
+			want: kvpUpdated,
+		},
+		{
+			name:   "dry run",
+			dryRun: true,
+			setup:  func(*mockapi.Client) {},
+			want:   kvpCreated,
+		},
+		{
+			name:   "upsert dry run",
+			upsert: true,
+			dryRun: true,
+			setup:  existing,
+			want:   kvpUpdated,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &mockapi.Client{}
+			tc.setup(client)
+
+			action, err := addKVP(ctx, client, "repo1", "owner", &value, tc.upsert, tc.dryRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if action != tc.want {
+				t.Errorf("want action %q, got %q", tc.want, action)
+			}
+			client.AssertExpectations(t)
+			if tc.dryRun {
+				client.AssertNotCalled(t, "NewRequest", addKVPMutation, mock.Anything)
+				client.AssertNotCalled(t, "NewRequest", updateKVPMutation, mock.Anything)
+			}
+		})
+	}
+}
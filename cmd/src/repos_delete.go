@@ -8,11 +8,17 @@ import (
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 
 	"github.com/sourcegraph/src-cli/internal/api"
+	"github.com/sourcegraph/src-cli/internal/cmderrors"
 )
 
 func init() {
 	flagSet := flag.NewFlagSet("delete", flag.ExitOnError)
-	apiFlags := api.NewFlags(flagSet)
+	var (
+		repoIDFlag = flagSet.String("repo", "", "The ID of a repository to delete.")
+		queryFlag  = flagSet.String("query", "", `Delete all repositories whose names match the query, as listed by 'src repos list -query'. (e.g. "myorg/archived-")`)
+		yesFlag    = flagSet.Bool("y", false, "Skip the confirmation prompt when deleting repositories matching -query.")
+		apiFlags   = api.NewFlags(flagSet)
+	)
 
 	printUsage := func() {
 		fmt.Fprintf(flag.CommandLine.Output(), "Usage of 'src repos %s'\n", flagSet.Name())
@@ -25,6 +31,16 @@ Examples:
    Delete one or more repositories:
 
     	$ src repos delete github.com/my/repo github.com/my/repo2
+
+   Delete a repository by ID:
+
+    	$ src repos delete -repo=UmVwb3NpdG9yeToxMjM=
+
+   Delete all repositories whose names match a query, after confirmation:
+
+    	$ src repos delete -query='github.com/my/archived-'
+
+   Use -y to skip the confirmation, e.g. in scripts.
 `
 		fmt.Fprint(flag.CommandLine.Output(), examples)
 	}
@@ -34,39 +50,54 @@ Examples:
 		if err != nil {
 			return err
 		}
-
-		query := `mutation DeleteRepository($repoID: ID!){
-			deleteRepository(repository: $repoID) {
-				alwaysNil
-			}
-		}`
-		var result struct{}
-		if ok, err := client.NewRequest(query, map[string]interface{}{
-			"repoID": repoID,
-		}).Do(ctx, &result); err != nil || !ok {
-			return err
-		}
-
-		fmt.Fprintf(flag.CommandLine.Output(), "Repository %q deleted\n", repoName)
-		return nil
+		return deleteRepositoryByID(ctx, client, repoID, repoName)
 	}
 
 	deleteRepositories := func(args []string) error {
 		if err := flagSet.Parse(args); err != nil {
 			return err
 		}
+		if flagSet.NArg() == 0 && *repoIDFlag == "" && *queryFlag == "" {
+			return cmderrors.Usage("expected repository names, -repo, or -query")
+		}
 
 		ctx := context.Background()
 		client := cfg.apiClient(apiFlags, flagSet.Output())
 
-		var errs errors.MultiError
+		var (
+			errs    errors.MultiError
+			deleted int
+		)
 		for _, repoName := range flagSet.Args() {
 			err := deleteRepository(ctx, client, repoName)
 			if err != nil {
 				err = errors.Wrapf(err, "Failed to delete repository %q", repoName)
 				errs = errors.Append(errs, err)
+				continue
+			}
+			deleted++
+		}
+
+		if *repoIDFlag != "" {
+			if err := deleteRepositoryByID(ctx, client, *repoIDFlag, *repoIDFlag); err != nil {
+				errs = errors.Append(errs, errors.Wrapf(err, "Failed to delete repository %q", *repoIDFlag))
+			} else {
+				deleted++
+			}
+		}
+
+		if *queryFlag != "" {
+			confirm := func(n int) (bool, error) {
+				return verify(fmt.Sprintf("Do you wish to delete these %d repositories from %s", n, cfg.Endpoint))
+			}
+			n, err := deleteRepositoriesMatching(ctx, client, *queryFlag, *yesFlag, confirm)
+			deleted += n
+			if err != nil {
+				errs = errors.Append(errs, err)
 			}
 		}
+
+		fmt.Fprintf(flag.CommandLine.Output(), "%d repositories deleted\n", deleted)
 		return errs
 	}
 
@@ -77,3 +108,72 @@ Examples:
 		usageFunc: printUsage,
 	})
 }
+
+const deleteRepositoryMutation = `mutation DeleteRepository($repoID: ID!){
+	deleteRepository(repository: $repoID) {
+		alwaysNil
+	}
+}`
+
+func deleteRepositoryByID(ctx context.Context, client api.Client, repoID, display string) error {
+	var result struct{}
+	if ok, err := client.NewRequest(deleteRepositoryMutation, map[string]interface{}{
+		"repoID": repoID,
+	}).Do(ctx, &result); err != nil || !ok {
+		return err
+	}
+
+	fmt.Fprintf(flag.CommandLine.Output(), "Repository %q deleted\n", display)
+	return nil
+}
+
+// deleteRepositoriesMatching deletes the repositories whose names match the
+// query. Unless yes is set, the repositories are printed, and they are only
+// deleted if confirm, called with their number, returns true. It returns the
+// number of repositories deleted, and the errors deleting the others.
+func deleteRepositoriesMatching(ctx context.Context, client api.Client, query string, yes bool, confirm func(n int) (bool, error)) (int, error) {
+	repos, err := listRepositories(ctx, client, listRepositoriesOpts{
+		Limit:      -1,
+		Query:      query,
+		Cloned:     true,
+		NotCloned:  true,
+		Indexed:    true,
+		NotIndexed: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(repos) == 0 {
+		fmt.Printf("No repositories match %q.\n", query)
+		return 0, nil
+	}
+	if !yes {
+		for _, repo := range repos {
+			fmt.Println(repo.Name)
+		}
+		confirmed, err := confirm(len(repos))
+		if err != nil {
+			return 0, err
+		}
+		if !confirmed {
+			fmt.Println("Aborting deletion.")
+			return 0, nil
+		}
+	}
+
+	var (
+		errs    errors.MultiError
+		deleted int
+	)
+	for _, repo := range repos {
+		if err := deleteRepositoryByID(ctx, client, repo.ID, repo.Name); err != nil {
+			errs = errors.Append(errs, errors.Wrapf(err, "Failed to delete repository %q", repo.Name))
+			continue
+		}
+		deleted++
+	}
+	if errs != nil {
+		return deleted, errs
+	}
+	return deleted, nil
+}
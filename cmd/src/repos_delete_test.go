@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	mockapi "github.com/sourcegraph/src-cli/internal/api/mock"
+)
+
+func TestDeleteRepositoriesMatching(t *testing.T) {
+	ctx := context.Background()
+
+	listing := func(client *mockapi.Client) {
+		req := &mockapi.Request{Response: `{"repositories": {"nodes": [{"id": "r1", "name": "github.com/my/archived-a"}, {"id": "r2", "name": "github.com/my/archived-b"}], "pageInfo": {"hasNextPage": false}}}`}
+		req.On("Do", mock.Anything, mock.Anything).Return(true, nil).Once()
+		client.On("NewRequest", listRepositoriesQuery, mock.Anything).Return(req).Once()
+	}
+	deletion := func(client *mockapi.Client, repoID string) {
+		req := &mockapi.Request{}
+		req.On("Do", mock.Anything, mock.Anything).Return(true, nil).Once()
+		client.On("NewRequest", deleteRepositoryMutation, map[string]interface{}{"repoID": repoID}).Return(req).Once()
+	}
+
+	for _, tc := range []struct {
+		name      string
+		yes       bool
+		confirmed bool
+		wantAsked bool
+		want      int
+	}{
+		{name: "confirmed", confirmed: true, wantAsked: true, want: 2},
+		{name: "declined", confirmed: false, wantAsked: true, want: 0},
+		{name: "-y", yes: true, want: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &mockapi.Client{}
+			listing(client)
+			if tc.want > 0 {
+				deletion(client, "r1")
+				deletion(client, "r2")
+			}
+
+			asked := false
+			confirm := func(n int) (bool, error) {
+				asked = true
+				if n != 2 {
+					t.Errorf("confirm called with %d repositories, want 2", n)
+				}
+				return tc.confirmed, nil
+			}
+
+			deleted, err := deleteRepositoriesMatching(ctx, client, "github.com/my/archived-", tc.yes, confirm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if deleted != tc.want {
+				t.Errorf("deleted %d repositories, want %d", deleted, tc.want)
+			}
+			if asked != tc.wantAsked {
+				t.Errorf("asked for confirmation: %v, want %v", asked, tc.wantAsked)
+			}
+			client.AssertExpectations(t)
+			if tc.want == 0 {
+				client.AssertNotCalled(t, "NewRequest", deleteRepositoryMutation, mock.Anything)
+			}
+		})
+	}
+}
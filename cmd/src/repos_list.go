@@ -21,14 +21,22 @@ Examples:
 
     	$ src repos list -f '{{.|json}}'
 
+  Print all repositories as a single JSON array:
+
+    	$ src repos list -limit='-1' -json
+
   List *all* repositories (may be slow!):
 
-    	$ src repos list -first='-1'
+    	$ src repos list -limit='-1'
 
   List repositories whose names match the query:
 
     	$ src repos list -query='myquery'
 
+  List repositories that are cloned but not yet indexed:
+
+    	$ src repos list -not-cloned=false -indexed=false
+
 `
 
 	flagSet := flag.NewFlagSet("list", flag.ExitOnError)
@@ -38,7 +46,8 @@ Examples:
 		fmt.Println(usage)
 	}
 	var (
-		firstFlag = flagSet.Int("first", 1000, "Returns the first n repositories from the list. (use -1 for unlimited)")
+		limitFlag = flagSet.Int("limit", 1000, "Returns at most n repositories from the list. Results are fetched in pages until the limit is reached. (use -1 for unlimited)")
+		firstFlag = flagSet.Int("first", 1000, "Deprecated: use -limit instead.")
 		queryFlag = flagSet.String("query", "", `Returns repositories whose names match the query. (e.g. "myorg/")`)
 		// TODO: add support for "names" field.
 		clonedFlag           = flagSet.Bool("cloned", true, "Include cloned repositories.")
@@ -47,7 +56,8 @@ Examples:
 		notIndexedFlag       = flagSet.Bool("not-indexed", true, "Include repositories that do not have a text search index.")
 		orderByFlag          = flagSet.String("order-by", "name", `How to order the results; possible choices are: "name", "created-at"`)
 		descendingFlag       = flagSet.Bool("descending", false, "Whether or not results should be in descending order.")
-		namesWithoutHostFlag = flagSet.Bool("names-without-host", false, "Whether or not repository names should be printed without the hostname (or other first path component). If set, -f is ignored.")
+		namesWithoutHostFlag = flagSet.Bool("names-without-host", false, "Whether or not repository names should be printed without the hostname (or other first path component). If set, -f and -json are ignored.")
+		jsonFlag             = flagSet.Bool("json", false, "Print the repositories as a single JSON array. If set, -f is ignored.")
 		formatFlag           = flagSet.String("f", "{{.Name}}", `Format for the output, using the syntax of Go package text/template. (e.g. "{{.ID}}: {{.Name}}") or "{{.|json}}")`)
 		apiFlags             = api.NewFlags(flagSet)
 	)
@@ -64,8 +74,91 @@ Examples:
 			return err
 		}
 
-		query := `query Repositories(
+		limit := *limitFlag
+		if isFlagSet(flagSet, "first") && !isFlagSet(flagSet, "limit") {
+			limit = *firstFlag
+		}
+
+		var orderBy string
+		switch *orderByFlag {
+		case "name":
+			orderBy = "REPOSITORY_NAME"
+		case "created-at":
+			orderBy = "REPO_CREATED_AT"
+		default:
+			return fmt.Errorf("invalid -order-by flag value: %q", *orderByFlag)
+		}
+
+		repos, err := listRepositories(context.Background(), client, listRepositoriesOpts{
+			Limit:      limit,
+			Query:      *queryFlag,
+			Cloned:     *clonedFlag,
+			NotCloned:  *notClonedFlag,
+			Indexed:    *indexedFlag,
+			NotIndexed: *notIndexedFlag,
+			OrderBy:    orderBy,
+			Descending: *descendingFlag,
+		})
+		if err != nil || repos == nil {
+			return err
+		}
+
+		if *namesWithoutHostFlag {
+			for _, repo := range repos {
+				firstSlash := strings.Index(repo.Name, "/")
+				fmt.Println(repo.Name[firstSlash+len("/"):])
+			}
+			return nil
+		}
+
+		if *jsonFlag {
+			data, err := marshalIndent(repos)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		for _, repo := range repos {
+			if err := execTemplate(tmpl, repo); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Register the command.
+	reposCommands = append(reposCommands, &command{
+		flagSet:   flagSet,
+		handler:   handler,
+		usageFunc: usageFunc,
+	})
+}
+
+// reposListPageSize is the number of repositories requested per page when
+// following the repositories connection cursor.
+const reposListPageSize = 1000
+
+// listRepositoriesOpts are the filters that can be applied to the
+// repositories connection by listRepositories.
+type listRepositoriesOpts struct {
+	// Limit is the maximum number of repositories to return. -1 means
+	// unlimited.
+	Limit int
+
+	Query      string
+	Cloned     bool
+	NotCloned  bool
+	Indexed    bool
+	NotIndexed bool
+	OrderBy    string
+	Descending bool
+}
+
+const listRepositoriesQuery = `query Repositories(
   $first: Int,
+  $after: String,
   $query: String,
   $cloned: Boolean,
   $notCloned: Boolean,
@@ -76,6 +169,7 @@ Examples:
 ) {
   repositories(
     first: $first,
+    after: $after,
     query: $query,
     cloned: $cloned,
     notCloned: $notCloned,
@@ -87,56 +181,62 @@ Examples:
     nodes {
       ...RepositoryFields
     }
+    pageInfo {
+      hasNextPage
+      endCursor
+    }
   }
 }
 ` + repositoryFragment
 
-		var orderBy string
-		switch *orderByFlag {
-		case "name":
-			orderBy = "REPOSITORY_NAME"
-		case "created-at":
-			orderBy = "REPO_CREATED_AT"
-		default:
-			return fmt.Errorf("invalid -order-by flag value: %q", *orderByFlag)
+// listRepositories follows the repositories connection cursor until either
+// all matching repositories have been fetched or opts.Limit is reached.
+//
+// A nil slice and nil error are returned if no data was available, for
+// example because -get-curl was set.
+func listRepositories(ctx context.Context, client api.Client, opts listRepositoriesOpts) ([]Repository, error) {
+	if opts.OrderBy == "" {
+		opts.OrderBy = "REPOSITORY_NAME"
+	}
+
+	repos := []Repository{}
+	var after *string
+	for opts.Limit == -1 || len(repos) < opts.Limit {
+		first := reposListPageSize
+		if opts.Limit != -1 && opts.Limit-len(repos) < first {
+			first = opts.Limit - len(repos)
 		}
 
 		var result struct {
 			Repositories struct {
-				Nodes []Repository
+				Nodes    []Repository
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   *string
+				}
 			}
 		}
-		if ok, err := client.NewRequest(query, map[string]interface{}{
-			"first":      api.NullInt(*firstFlag),
-			"query":      api.NullString(*queryFlag),
-			"cloned":     *clonedFlag,
-			"notCloned":  *notClonedFlag,
-			"indexed":    *indexedFlag,
-			"notIndexed": *notIndexedFlag,
-			"orderBy":    orderBy,
-			"descending": *descendingFlag,
-		}).Do(context.Background(), &result); err != nil || !ok {
-			return err
+		if ok, err := client.NewRequest(listRepositoriesQuery, map[string]interface{}{
+			"first":      first,
+			"after":      after,
+			"query":      api.NullString(opts.Query),
+			"cloned":     opts.Cloned,
+			"notCloned":  opts.NotCloned,
+			"indexed":    opts.Indexed,
+			"notIndexed": opts.NotIndexed,
+			"orderBy":    opts.OrderBy,
+			"descending": opts.Descending,
+		}).Do(ctx, &result); err != nil || !ok {
+			return nil, err
 		}
 
-		for _, repo := range result.Repositories.Nodes {
-			if *namesWithoutHostFlag {
-				firstSlash := strings.Index(repo.Name, "/")
-				fmt.Println(repo.Name[firstSlash+len("/"):])
-				continue
-			}
+		repos = append(repos, result.Repositories.Nodes...)
 
-			if err := execTemplate(tmpl, repo); err != nil {
-				return err
-			}
+		pageInfo := result.Repositories.PageInfo
+		if !pageInfo.HasNextPage || pageInfo.EndCursor == nil {
+			break
 		}
-		return nil
+		after = pageInfo.EndCursor
 	}
-
-	// Register the command.
-	reposCommands = append(reposCommands, &command{
-		flagSet:   flagSet,
-		handler:   handler,
-		usageFunc: usageFunc,
-	})
+	return repos, nil
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/mock"
+
+	mockapi "github.com/sourcegraph/src-cli/internal/api/mock"
+)
+
+func TestListRepositories(t *testing.T) {
+	ctx := context.Background()
+
+	pages := []string{
+		`{"repositories": {"nodes": [{"name": "a"}, {"name": "b"}], "pageInfo": {"hasNextPage": true, "endCursor": "cursor-1"}}}`,
+		`{"repositories": {"nodes": [{"name": "c"}], "pageInfo": {"hasNextPage": false, "endCursor": null}}}`,
+	}
+
+	afterCursor := func(want string) func(map[string]interface{}) bool {
+		return func(vars map[string]interface{}) bool {
+			after := vars["after"].(*string)
+			if want == "" {
+				return after == nil
+			}
+			return after != nil && *after == want
+		}
+	}
+
+	names := func(repos []Repository) []string {
+		var names []string
+		for _, r := range repos {
+			names = append(names, r.Name)
+		}
+		return names
+	}
+
+	t.Run("follows cursor", func(t *testing.T) {
+		client := &mockapi.Client{}
+		for i, cursor := range []string{"", "cursor-1"} {
+			req := &mockapi.Request{Response: pages[i]}
+			req.On("Do", mock.Anything, mock.Anything).Return(true, nil).Once()
+			client.On("NewRequest", listRepositoriesQuery, mock.MatchedBy(afterCursor(cursor))).Return(req).Once()
+		}
+
+		repos, err := listRepositories(ctx, client, listRepositoriesOpts{Limit: -1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"a", "b", "c"}, names(repos)); diff != "" {
+			t.Errorf("unexpected repositories (-want +got):\n%s", diff)
+		}
+		client.AssertExpectations(t)
+	})
+
+	t.Run("respects limit", func(t *testing.T) {
+		client := &mockapi.Client{}
+		req := &mockapi.Request{Response: pages[0]}
+		req.On("Do", mock.Anything, mock.Anything).Return(true, nil).Once()
+		client.On("NewRequest", listRepositoriesQuery, mock.MatchedBy(func(vars map[string]interface{}) bool {
+			return vars["first"] == 2
+		})).Return(req).Once()
+
+		repos, err := listRepositories(ctx, client, listRepositoriesOpts{Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"a", "b"}, names(repos)); diff != "" {
+			t.Errorf("unexpected repositories (-want +got):\n%s", diff)
+		}
+		client.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/src-cli/internal/api"
+	"github.com/sourcegraph/src-cli/internal/cmderrors"
+)
+
+func init() {
+	usage := `
+Examples:
+
+  Compare the repositories on the instance with a list of repositories on a code
+  host, one per line:
+
+    	$ src repos reconcile -from-file=codehost-repos.txt
+
+  Compare only the instance's repositories of an organization, with a code host
+  list that omits the hostname:
+
+    	$ src repos reconcile -from-file=codehost-repos.txt -query=github.com/myorg/ -prefix=github.com/
+
+  Read the code host list from standard input and print the result as JSON:
+
+    	$ gh repo list myorg --json nameWithOwner -q '.[].nameWithOwner' | src repos reconcile -from-file=- -prefix=github.com/ -json
+
+Lines of the code host list may be repository names (e.g. github.com/myorg/repo)
+or clone URLs (e.g. https://github.com/myorg/repo.git or
+git@github.com:myorg/repo.git). Blank lines and lines starting with # are
+ignored. Names are compared case-insensitively.
+`
+
+	flagSet := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	usageFunc := func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of 'src repos %s':\n", flagSet.Name())
+		flagSet.PrintDefaults()
+		fmt.Println(usage)
+	}
+	var (
+		fromFileFlag = flagSet.String("from-file", "", "The file listing the repositories on the code host, one per line, or - to read from standard input.")
+		prefixFlag   = flagSet.String("prefix", "", `Prefix to add to the repository names in the code host list that are not URLs. (e.g. "github.com/")`)
+		queryFlag    = flagSet.String("query", "", `Only compare the instance's repositories whose names match the query. (e.g. "github.com/myorg/")`)
+		jsonFlag     = flagSet.Bool("json", false, "Print the result as JSON.")
+		apiFlags     = api.NewFlags(flagSet)
+	)
+
+	handler := func(args []string) error {
+		if err := flagSet.Parse(args); err != nil {
+			return err
+		}
+		if *fromFileFlag == "" {
+			return cmderrors.Usage("-from-file must be specified")
+		}
+
+		var r io.Reader = os.Stdin
+		if *fromFileFlag != "-" {
+			f, err := os.Open(*fromFileFlag)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		codeHostRepos, err := readCodeHostRepoList(r, *prefixFlag)
+		if err != nil {
+			return errors.Wrapf(err, "reading %s", *fromFileFlag)
+		}
+
+		client := cfg.apiClient(apiFlags, flagSet.Output())
+		repos, err := listRepositories(context.Background(), client, listRepositoriesOpts{
+			Limit:      -1,
+			Query:      *queryFlag,
+			Cloned:     true,
+			NotCloned:  true,
+			Indexed:    true,
+			NotIndexed: true,
+		})
+		if err != nil || repos == nil {
+			return err
+		}
+		instanceRepos := make([]string, 0, len(repos))
+		for _, repo := range repos {
+			instanceRepos = append(instanceRepos, repo.Name)
+		}
+
+		result := reconcileRepos(instanceRepos, codeHostRepos)
+		if *jsonFlag {
+			data, err := marshalIndent(result)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		printReposReconciliation(os.Stdout, result)
+		return nil
+	}
+
+	// Register the command.
+	reposCommands = append(reposCommands, &command{
+		flagSet:   flagSet,
+		handler:   handler,
+		usageFunc: usageFunc,
+	})
+}
+
+// reposReconciliation is the result of comparing the repositories on the
+// instance with those on a code host.
+type reposReconciliation struct {
+	OnlyOnInstance []string `json:"onlyOnInstance"`
+	OnlyOnCodeHost []string `json:"onlyOnCodeHost"`
+	OnBoth         []string `json:"onBoth"`
+}
+
+// readCodeHostRepoList reads a list of repository names or clone URLs, one per
+// line, and normalizes them to repository names. Clone URLs may also be given in
+// the scp-like form user@host:path. The prefix is added to names that are not
+// URLs.
+func readCodeHostRepoList(r io.Reader, prefix string) ([]string, error) {
+	var repos []string
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, rest, ok := strings.Cut(line, "://"); ok {
+			// Clone URLs already include the hostname, but may include
+			// credentials.
+			line = rest
+			if at := strings.Index(line, "@"); at >= 0 && at < strings.Index(line+"/", "/") {
+				line = line[at+1:]
+			}
+		} else if host, path, ok := strings.Cut(line, ":"); ok && !strings.Contains(host, "/") {
+			// scp-like clone URLs such as git@github.com:myorg/repo.git.
+			if at := strings.LastIndex(host, "@"); at >= 0 {
+				host = host[at+1:]
+			}
+			path = strings.TrimPrefix(path, "/")
+			if host == "" || path == "" {
+				return nil, errors.Newf("line %d: invalid clone URL %q", lineNum, line)
+			}
+			line = host + "/" + path
+		} else {
+			line = prefix + line
+		}
+		repos = append(repos, strings.TrimSuffix(strings.TrimSuffix(line, "/"), ".git"))
+	}
+	return repos, scanner.Err()
+}
+
+// reconcileRepos compares the names of the repositories on the instance with
+// those on the code host, ignoring case. The names in the result are sorted, and
+// the instance's spelling is used for repositories on both.
+func reconcileRepos(instanceRepos, codeHostRepos []string) reposReconciliation {
+	onCodeHost := map[string]string{}
+	for _, name := range codeHostRepos {
+		onCodeHost[strings.ToLower(name)] = name
+	}
+
+	result := reposReconciliation{
+		OnlyOnInstance: []string{},
+		OnlyOnCodeHost: []string{},
+		OnBoth:         []string{},
+	}
+	onInstance := map[string]bool{}
+	for _, name := range instanceRepos {
+		key := strings.ToLower(name)
+		onInstance[key] = true
+		if _, ok := onCodeHost[key]; ok {
+			result.OnBoth = append(result.OnBoth, name)
+		} else {
+			result.OnlyOnInstance = append(result.OnlyOnInstance, name)
+		}
+	}
+	for key, name := range onCodeHost {
+		if !onInstance[key] {
+			result.OnlyOnCodeHost = append(result.OnlyOnCodeHost, name)
+		}
+	}
+
+	sort.Strings(result.OnlyOnInstance)
+	sort.Strings(result.OnlyOnCodeHost)
+	sort.Strings(result.OnBoth)
+	return result
+}
+
+func printReposReconciliation(w io.Writer, result reposReconciliation) {
+	for _, section := range []struct {
+		title string
+		repos []string
+	}{
+		{"Only on the instance", result.OnlyOnInstance},
+		{"Only on the code host", result.OnlyOnCodeHost},
+		{"On both", result.OnBoth},
+	} {
+		fmt.Fprintf(w, "%s (%d):\n", section.title, len(section.repos))
+		for _, repo := range section.repos {
+			fmt.Fprintf(w, "  %s\n", repo)
+		}
+	}
+}
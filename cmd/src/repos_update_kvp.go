@@ -60,21 +60,7 @@ Examples:
 
 		client := cfg.apiClient(apiFlags, flagSet.Output())
 
-		query := `mutation updateKVP(
-  $repo: ID!,
-  $key: String!,
-  $value: String,
-) {
-  updateRepoKeyValuePair(
-    repo: $repo,
-    key: $key,
-    value: $value,
-  ) {
-    alwaysNil
-  }
-}`
-
-		if ok, err := client.NewRequest(query, map[string]interface{}{
+		if ok, err := client.NewRequest(updateKVPMutation, map[string]interface{}{
 			"repo":  *repoFlag,
 			"key":   *keyFlag,
 			"value": valueFlag,
@@ -97,3 +83,17 @@ Examples:
 		usageFunc: usageFunc,
 	})
 }
+
+const updateKVPMutation = `mutation updateKVP(
+  $repo: ID!,
+  $key: String!,
+  $value: String,
+) {
+  updateRepoKeyValuePair(
+    repo: $repo,
+    key: $key,
+    value: $value,
+  ) {
+    alwaysNil
+  }
+}`
@@ -37,6 +37,49 @@ Examples:
 
     	$ src search -json 'repogroup:sample error'
 
+  Get the results wrapped in the JSON envelope shared by other src commands:
+
+    	$ src -output-format=json search 'repogroup:sample error'
+
+  Save a search query and run it later, optionally adding more terms:
+
+    	$ src search saved add errors 'repogroup:sample error'
+    	$ src search -saved=errors 'lang:go'
+
+  See 'src search saved -h' for more information about saved searches.
+
+  Show results under a header for each repository, with its number of matches:
+
+    	$ src search -group-by=repo 'repogroup:sample error'
+
+  Scope every search to an organization's repositories and set a default pattern
+  type and timeout, by adding the following to the src config file:
+
+    	"searchDefaults": {
+    		"queryPrefix": "repo:^github\\.com/acme/",
+    		"patternType": "regexp",
+    		"timeout": "30s"
+    	}
+
+  The defaults are applied to every query; a patternType: or timeout: parameter
+  in the query takes precedence. Run a search without them:
+
+    	$ src search -no-defaults 'repogroup:sample error'
+
+  List the distinct files or symbols matched, with their number of matches:
+
+    	$ src search -dedup=file 'repogroup:sample error'
+    	$ src search -dedup=symbol 'repogroup:sample type:symbol Handler'
+
+  Write the matches to a SARIF file for security tooling, e.g. GitHub code
+  scanning:
+
+    	$ src search -sarif=results.sarif 'repo:^github\.com/acme/app$ patterntype:regexp md5\.New\('
+
+  Show 3 lines of context around each matching line, like 'grep -C 3':
+
+    	$ src search -context=3 'repogroup:sample error'
+
 Other tips:
 
   Make 'type:diff' searches have colored diffs by installing https://colordiff.org
@@ -44,7 +87,8 @@ Other tips:
     - Mac OS:        $ brew install colordiff
     - Windows:       $ npm install -g colordiff
 
-  Disable color output by setting NO_COLOR=t (see https://no-color.org).
+  Disable color output by setting NO_COLOR=t (see https://no-color.org), or
+  only the highlighting of matches with -no-highlight.
 
   Force color output on (not on by default when piped to other programs) by setting COLOR=t
 
@@ -65,6 +109,13 @@ Other tips:
 		lessFlag        = flagSet.Bool("less", true, "Pipe output to 'less -R' (only if stdout is terminal, and not json flag).")
 		streamFlag      = flagSet.Bool("stream", false, "Consume results as stream. Streaming search only supports a subset of flags and parameters: trace, insecure-skip-verify, display, json.")
 		display         = flagSet.Int("display", -1, "Limit the number of results that are displayed. Only supported together with stream flag. Statistics continue to report all results.")
+		contextFlag     = flagSet.Int("context", 0, "Number of lines of context to show before and after each matching line. In -json mode, context is included in the 'before' and 'after' fields of each line match. Not supported together with stream flag.")
+		groupByFlag     = flagSet.String("group-by", "", `Group results by "repo", rendering them under a header for each repository with its number of matches. In -json mode, results are printed as an object mapping each repository to its results. Not supported together with stream flag.`)
+		savedFlag       = flagSet.String("saved", "", "Run the saved search with the given name (see 'src search saved'). Any query given as an argument is appended to the saved query.")
+		dedupFlag       = flagSet.String("dedup", "", `Collapse file matches into one line per distinct "file" or "symbol", with its number of matches. In -json mode, the groups are printed as a list. Not supported together with stream flag.`)
+		noDefaultsFlag  = flagSet.Bool("no-defaults", false, "Do not apply the searchDefaults from the src config file to the query.")
+		noHighlightFlag = flagSet.Bool("no-highlight", false, "Do not highlight the matched ranges of results in color.")
+		sarifFlag       = flagSet.String("sarif", "", "Write the matched ranges of file results to this file in SARIF 2.1.0 format, with the query as the rule, instead of printing the results. Use - for standard output. Not supported together with stream flag.")
 	)
 
 	handler := func(args []string) error {
@@ -72,14 +123,66 @@ Other tips:
 			return err
 		}
 
+		if flagSet.Arg(0) == "saved" && flagSet.NArg() > 1 {
+			searchSavedCommands.run(searchSavedFlagSet, "src search saved", searchSavedUsage, flagSet.Args()[1:])
+			return nil
+		}
+
+		if *noHighlightFlag {
+			ansiColors["search-match"] = ""
+		}
+
+		queryString := flagSet.Arg(0)
+		if *savedFlag != "" {
+			var err error
+			if queryString, err = resolveSavedSearch(*savedFlag, flagSet.Args()); err != nil {
+				return err
+			}
+		}
+
+		if cfg.SearchDefaults != nil && !*noDefaultsFlag {
+			queryString = applySearchDefaults(queryString, *cfg.SearchDefaults)
+		}
+
+		if *contextFlag < 0 {
+			return cmderrors.Usage("-context must not be negative")
+		}
+		if *groupByFlag != "" && *groupByFlag != "repo" {
+			return cmderrors.Usagef("invalid -group-by %q: only \"repo\" is supported", *groupByFlag)
+		}
+		if *dedupFlag != "" && *dedupFlag != searchDedupFile && *dedupFlag != searchDedupSymbol {
+			return cmderrors.Usagef("invalid -dedup %q: must be %q or %q", *dedupFlag, searchDedupFile, searchDedupSymbol)
+		}
+		if *dedupFlag != "" && *groupByFlag != "" {
+			return cmderrors.Usage("-dedup is not supported together with -group-by")
+		}
+		if *sarifFlag != "" && (*dedupFlag != "" || *groupByFlag != "" || *jsonFlag || jsonOutput()) {
+			return cmderrors.Usage("-sarif is not supported together with -dedup, -group-by, or JSON output")
+		}
+
 		if *streamFlag {
+			if jsonOutput() {
+				return cmderrors.Usage("-output-format=json is not supported together with -stream, use -json instead")
+			}
+			if *contextFlag > 0 {
+				return cmderrors.Usage("-context is not supported together with -stream")
+			}
+			if *groupByFlag != "" {
+				return cmderrors.Usage("-group-by is not supported together with -stream")
+			}
+			if *dedupFlag != "" {
+				return cmderrors.Usage("-dedup is not supported together with -stream")
+			}
+			if *sarifFlag != "" {
+				return cmderrors.Usage("-sarif is not supported together with -stream")
+			}
 			opts := streaming.Opts{
 				Display: *display,
 				Trace:   apiFlags.Trace(),
 				Json:    *jsonFlag,
 			}
 			client := cfg.apiClient(apiFlags, flagSet.Output())
-			return streamSearch(flagSet.Arg(0), opts, client, os.Stdout)
+			return streamSearch(queryString, opts, client, os.Stdout)
 		}
 
 		if *explainJSONFlag {
@@ -87,13 +190,12 @@ Other tips:
 			return nil
 		}
 
-		if flagSet.NArg() != 1 {
+		if *savedFlag == "" && flagSet.NArg() != 1 {
 			return cmderrors.Usage("expected exactly one argument: the search query")
 		}
-		queryString := flagSet.Arg(0)
 
 		// For pagination, pipe our own output to 'less -R'
-		if *lessFlag && !*jsonFlag {
+		if *lessFlag && !*jsonFlag && !jsonOutput() && *sarifFlag == "" {
 			// But first we check whether we can use `less`. (Instead of
 			// combining the conditions here into one, we use a 2nd conditional
 			// so we don't need to do `exec.LookPath` if flags disable `less`)
@@ -151,6 +253,11 @@ Other tips:
 					offsetAndLengths
 					limitHit
 				}
+				symbols {
+					name
+					containerName
+					kind
+				}
 			}
 
 			fragment CommitSearchResultFields on CommitSearchResult {
@@ -265,6 +372,10 @@ Other tips:
 			return err
 		}
 
+		if *contextFlag > 0 {
+			addSearchMatchContext(result.Search.Results.Results, *contextFlag)
+		}
+
 		improved := searchResultsImproved{
 			SourcegraphEndpoint: cfg.Endpoint,
 			Query:               queryString,
@@ -272,6 +383,51 @@ Other tips:
 			searchResults:       result.Search.Results,
 		}
 
+		if *sarifFlag != "" {
+			return writeSearchSARIFFile(*sarifFlag, improved, flagSet.Output())
+		}
+
+		if *dedupFlag != "" {
+			groups := dedupSearchResults(improved.Results, *dedupFlag)
+			if jsonOutput() {
+				return writeOutputEnvelope(os.Stdout, groups)
+			}
+			if *jsonFlag {
+				f, err := marshalIndent(groups)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(f))
+				return nil
+			}
+			printSearchDedupGroups(os.Stdout, groups, *dedupFlag)
+			return nil
+		}
+
+		if *groupByFlag == "repo" {
+			repos, groups := groupSearchResultsByRepo(improved.Results)
+			if jsonOutput() {
+				return writeOutputEnvelope(os.Stdout, groups)
+			}
+			if *jsonFlag {
+				f, err := marshalIndent(groups)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(f))
+				return nil
+			}
+
+			improved.Results = nil
+			for _, repo := range repos {
+				improved.Results = append(improved.Results, groups[repo]...)
+			}
+			improved.GroupByRepo = true
+		}
+
+		if jsonOutput() {
+			return writeOutputEnvelope(os.Stdout, improved)
+		}
 		if *jsonFlag {
 			// Print the formatted JSON.
 			f, err := marshalIndent(improved)
@@ -293,6 +449,10 @@ Other tips:
 	commands = append(commands, &command{
 		flagSet: flagSet,
 		handler: handler,
+		// 'src search saved' is dispatched by the handler, as search takes
+		// arbitrary arguments.
+		subcommands:  &commander{{flagSet: searchSavedFlagSet, subcommands: &searchSavedCommands}},
+		outputFormat: true,
 		usageFunc: func() {
 			fmt.Fprintf(flag.CommandLine.Output(), "Usage of 'src %s':\n", flagSet.Name())
 			flagSet.PrintDefaults()
@@ -319,6 +479,10 @@ type searchResultsImproved struct {
 	Query               string
 	Site                struct{ BuildVersion string }
 	searchResults
+
+	// GroupByRepo renders a header before the results of each repository, which
+	// are expected to be grouped.
+	GroupByRepo bool `json:"-"`
 }
 
 func envSetDefault(env []string, key, value string) []string {
@@ -453,6 +617,10 @@ func applyHighlightsForFile(fileContent string, highlights []highlight) string {
 	var result []rune
 	start := ansiColors["search-match"]
 	end := ansiColors["nc"]
+	if start == "" {
+		// Highlighting is disabled, e.g. with -no-highlight.
+		end = ""
+	}
 	lines := strings.Split(fileContent, "\n")
 	for _, highlight := range highlights {
 		line := lines[highlight.line]
@@ -471,6 +639,11 @@ func applyHighlightsForFile(fileContent string, highlights []highlight) string {
 // applyHighlights expects highlight information that applies relative to lines in
 // the input string, where the input string corresponds to the preview field in LineMatches.
 func applyHighlights(input string, highlights []highlight, start, end string) string {
+	if start == "" {
+		// Highlighting is disabled, e.g. with -no-highlight. Like highlighted
+		// output, each line is terminated by a newline.
+		return input + "\n"
+	}
 	var result []rune
 	lines := strings.Split(input, "\n")
 	for lineNumber, line := range lines {
@@ -518,10 +691,13 @@ var searchTemplateFuncs = map[string]interface{}{
 		if prevIndex < 0 {
 			return true
 		}
-		prevLineNumber := lineMatches[prevIndex].(map[string]interface{})["lineNumber"]
-		lineNumber := lineMatches[index].(map[string]interface{})["lineNumber"]
-		return prevLineNumber.(float64) == lineNumber.(float64)-1
+		// Account for any context lines, which are shown between line matches.
+		prevLastLine := len(searchContextLines(lineMatches[prevIndex], "after")) + int(lineMatches[prevIndex].(map[string]interface{})["lineNumber"].(float64))
+		firstLine := int(lineMatches[index].(map[string]interface{})["lineNumber"].(float64)) - len(searchContextLines(lineMatches[index], "before"))
+		return prevLastLine == firstLine-1
 	},
+	"searchContextLines":    searchContextLines,
+	"searchRepoGroupHeader": searchRepoGroupHeader,
 	"searchHighlightMatch": func(content, query, match interface{}) string {
 		m := match.(map[string]interface{})
 		q := query.(string)
@@ -595,7 +771,12 @@ const searchResultsTemplate = `{{- /* ignore this line for template formatting s
 	{{- searchAlertRender .Alert -}}
 
 {{- /* Rendering of results */ -}}
-	{{- range .Results -}}
+	{{- range $index, $result := .Results -}}
+		{{- /* The repository header for -group-by=repo */ -}}
+		{{- if $.GroupByRepo -}}
+			{{- searchRepoGroupHeader $.Results $index -}}
+		{{- end -}}
+
 		{{- if ne .__typename "Repository" -}}
 			{{- /* The border separating results */ -}}
 			{{- color "search-border"}}{{"--------------------------------------------------------------------------------\n"}}{{color "nc"}}
@@ -624,8 +805,16 @@ const searchResultsTemplate = `{{- /* ignore this line for template formatting s
 				{{- if not (searchSequentialLineNumber $lineMatches $index) -}}
 					{{- color "search-border"}}{{"  ------------------------------------------------------------------------------\n"}}{{color "nc"}}
 				{{- end -}}
+				{{- range searchContextLines $match "before" -}}
+					{{- "  "}}{{color "search-line-numbers"}}{{pad .Number 6 " "}}{{color "nc" -}}
+					{{- color "search-border"}}{{" |  "}}{{color "nc"}}{{.Line}}{{"\n"}}
+				{{- end -}}
 				{{- "  "}}{{color "search-line-numbers"}}{{pad (addFloat $match.lineNumber 1) 6 " "}}{{color "nc" -}}
 				{{- color "search-border"}}{{" |  "}}{{color "nc"}}{{searchHighlightMatch $content $.Query $match}}
+				{{- range searchContextLines $match "after" -}}
+					{{- "  "}}{{color "search-line-numbers"}}{{pad .Number 6 " "}}{{color "nc" -}}
+					{{- color "search-border"}}{{" |  "}}{{color "nc"}}{{.Line}}{{"\n"}}
+				{{- end -}}
 			{{- end -}}
 		{{- end -}}
 
@@ -713,6 +902,17 @@ At a high-level there are three result types:
 All three of these result types have different fields available. They can be
 differentiated by using the '__typename' field.
 
+With '-context=N', each of the 'lineMatches' of a 'FileMatch' additionally has
+'before' and 'after' fields with up to N lines of context around the line match.
+
+With '-group-by=repo', only the results are printed, as an object mapping each
+repository name to the list of its results.
+
+With '-dedup=file' or '-dedup=symbol', only a list of the distinct files or
+symbols is printed. Each entry has a 'count' of its matches; file entries have
+'repository' and 'path' fields, and symbol entries have 'symbol', 'kind' and
+'files' fields.
+
 The link below shows the GraphQL query that this program internally
 executes when querying for search results. On this page, you can hover over
 any field in the GraphQL panel on the left to get documentation about the field
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+)
+
+// addSearchMatchContext adds up to n lines of context before and after each line
+// match of the file match results, as "before" and "after" string slices on the
+// line match. Context is taken from the file content returned with the result.
+//
+// Each line of the file is included at most once: context never includes other
+// line matches (e.g. the other lines of a multi-line match), and when the context of
+// two line matches overlaps, the lines are attributed to the earlier match.
+func addSearchMatchContext(results []map[string]interface{}, n int) {
+	for _, r := range results {
+		if r["__typename"] != "FileMatch" {
+			continue
+		}
+		file, _ := r["file"].(map[string]interface{})
+		content, ok := file["content"].(string)
+		if !ok {
+			continue
+		}
+		lineMatches, _ := r["lineMatches"].([]interface{})
+
+		lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+		// next is the first line not yet shown as context or a match.
+		next := 0
+		for i, lm := range lineMatches {
+			m := lm.(map[string]interface{})
+			line := int(m["lineNumber"].(float64))
+			if line >= len(lines) {
+				continue
+			}
+
+			start := line - n
+			if start < next {
+				start = next
+			}
+			if start > line {
+				start = line
+			}
+
+			end := line + n
+			if end >= len(lines) {
+				end = len(lines) - 1
+			}
+			if i+1 < len(lineMatches) {
+				nextMatch := int(lineMatches[i+1].(map[string]interface{})["lineNumber"].(float64))
+				if nextMatch > line && end >= nextMatch {
+					end = nextMatch - 1
+				}
+			}
+
+			m["before"] = append([]string{}, lines[start:line]...)
+			m["after"] = append([]string{}, lines[line+1:end+1]...)
+			if end+1 > next {
+				next = end + 1
+			}
+		}
+	}
+}
+
+// searchContextLine is a line of context around a line match.
+type searchContextLine struct {
+	Number int // 1-indexed
+	Line   string
+}
+
+// searchContextLines returns the context lines added by addSearchMatchContext for
+// the given line match, where key is either "before" or "after".
+func searchContextLines(match interface{}, key string) []searchContextLine {
+	m := match.(map[string]interface{})
+	context, _ := m[key].([]string)
+	first := int(m["lineNumber"].(float64)) + 2 // 1-indexed line after the match
+	if key == "before" {
+		first = int(m["lineNumber"].(float64)) + 1 - len(context)
+	}
+
+	lines := make([]searchContextLine, 0, len(context))
+	for i, line := range context {
+		lines = append(lines, searchContextLine{Number: first + i, Line: line})
+	}
+	return lines
+}
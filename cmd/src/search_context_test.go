@@ -0,0 +1,101 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAddSearchMatchContext(t *testing.T) {
+	fileMatch := func(content string, lineNumbers ...int) map[string]interface{} {
+		var lineMatches []interface{}
+		for _, n := range lineNumbers {
+			lineMatches = append(lineMatches, map[string]interface{}{"lineNumber": float64(n)})
+		}
+		return map[string]interface{}{
+			"__typename":  "FileMatch",
+			"file":        map[string]interface{}{"content": content},
+			"lineMatches": lineMatches,
+		}
+	}
+
+	type context struct{ Before, After []string }
+	contexts := func(r map[string]interface{}) []context {
+		var got []context
+		for _, lm := range r["lineMatches"].([]interface{}) {
+			m := lm.(map[string]interface{})
+			got = append(got, context{m["before"].([]string), m["after"].([]string)})
+		}
+		return got
+	}
+
+	const content = "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+
+	cases := []struct {
+		name        string
+		lineNumbers []int
+		n           int
+		want        []context
+		sequential  bool // whether the line matches are displayed without gaps
+	}{{
+		name:        "single match",
+		lineNumbers: []int{4},
+		n:           2,
+		want:        []context{{[]string{"3", "4"}, []string{"6", "7"}}},
+	}, {
+		name:        "file boundaries",
+		lineNumbers: []int{0, 9},
+		n:           3,
+		want: []context{
+			{[]string{}, []string{"2", "3", "4"}},
+			{[]string{"7", "8", "9"}, []string{}},
+		},
+	}, {
+		name:        "multi-line match",
+		lineNumbers: []int{4, 5, 6},
+		n:           1,
+		want: []context{
+			{[]string{"4"}, []string{}},
+			{[]string{}, []string{}},
+			{[]string{}, []string{"8"}},
+		},
+		sequential: true,
+	}, {
+		name:        "overlapping context",
+		lineNumbers: []int{2, 5},
+		n:           2,
+		want: []context{
+			{[]string{"1", "2"}, []string{"4", "5"}},
+			{[]string{}, []string{"7", "8"}},
+		},
+		sequential: true,
+	}}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := fileMatch(content, tc.lineNumbers...)
+			addSearchMatchContext([]map[string]interface{}{r}, tc.n)
+			if diff := cmp.Diff(tc.want, contexts(r)); diff != "" {
+				t.Errorf("unexpected context (-want +got):\n%s", diff)
+			}
+
+			lineMatches := r["lineMatches"].([]interface{})
+			for i := 1; i < len(lineMatches); i++ {
+				sequential := searchTemplateFuncs["searchSequentialLineNumber"].(func([]interface{}, int) bool)(lineMatches, i)
+				if sequential != tc.sequential {
+					t.Errorf("line match %d: want sequential=%v, got %v", i, tc.sequential, sequential)
+				}
+			}
+		})
+	}
+
+	t.Run("context line numbers", func(t *testing.T) {
+		r := fileMatch(content, 4)
+		addSearchMatchContext([]map[string]interface{}{r}, 1)
+		match := r["lineMatches"].([]interface{})[0]
+		want := []searchContextLine{{Number: 4, Line: "4"}, {Number: 6, Line: "6"}}
+		got := append(searchContextLines(match, "before"), searchContextLines(match, "after")...)
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected context lines (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	searchDedupFile   = "file"
+	searchDedupSymbol = "symbol"
+)
+
+// searchDedupGroup is a distinct file or symbol in the search results, and the
+// number of times it matched.
+type searchDedupGroup struct {
+	Repository string `json:"repository,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Symbol     string `json:"symbol,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Count      int    `json:"count"`
+	// Files are the files a symbol was found in, as "repository/path".
+	Files []string `json:"files,omitempty"`
+}
+
+// dedupSearchResults collapses the file matches in the results into one group per
+// distinct file or symbol, depending on by. Groups are ordered by descending count.
+// Other results, such as commits and repositories, are ignored.
+func dedupSearchResults(results []map[string]interface{}, by string) []searchDedupGroup {
+	var (
+		groups  []searchDedupGroup
+		indices = map[string]int{}
+	)
+	add := func(key string, group searchDedupGroup, count int, file string) {
+		i, ok := indices[key]
+		if !ok {
+			i = len(groups)
+			indices[key] = i
+			groups = append(groups, group)
+		}
+		groups[i].Count += count
+		if file != "" && (len(groups[i].Files) == 0 || groups[i].Files[len(groups[i].Files)-1] != file) {
+			groups[i].Files = append(groups[i].Files, file)
+		}
+	}
+
+	for _, r := range results {
+		if r["__typename"] != "FileMatch" {
+			continue
+		}
+		repo := searchResultRepoName(r)
+		var path string
+		if file, ok := r["file"].(map[string]interface{}); ok {
+			path, _ = file["path"].(string)
+		}
+
+		switch by {
+		case searchDedupFile:
+			add(repo+"/"+path, searchDedupGroup{Repository: repo, Path: path}, searchResultMatchCount(r), "")
+		case searchDedupSymbol:
+			symbols, _ := r["symbols"].([]interface{})
+			for _, s := range symbols {
+				symbol, ok := s.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _ := symbol["name"].(string)
+				kind, _ := symbol["kind"].(string)
+				add(kind+" "+name, searchDedupGroup{Symbol: name, Kind: kind}, 1, repo+"/"+path)
+			}
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Count > groups[j].Count })
+	return groups
+}
+
+// printSearchDedupGroups prints a line per group with its count, followed by the
+// number of groups.
+func printSearchDedupGroups(w io.Writer, groups []searchDedupGroup, by string) {
+	for _, g := range groups {
+		fmt.Fprintf(w, "%s%6d%s  ", ansiColors["success"], g.Count, ansiColors["nc"])
+		if by == searchDedupSymbol {
+			fmt.Fprintf(w, "%s%s%s %s(%s, %d files)%s\n",
+				ansiColors["search-match"], g.Symbol, ansiColors["nc"],
+				ansiColors["search-border"], g.Kind, len(g.Files), ansiColors["nc"])
+			continue
+		}
+		fmt.Fprintf(w, "%s%s%s/%s%s%s\n",
+			ansiColors["search-repository"], g.Repository, ansiColors["nc"],
+			ansiColors["search-filename"], g.Path, ansiColors["nc"])
+	}
+	noun := "files"
+	if by == searchDedupSymbol {
+		noun = "symbols"
+	}
+	fmt.Fprintf(w, "%d distinct %s\n", len(groups), noun)
+}
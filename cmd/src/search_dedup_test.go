@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDedupSearchResults(t *testing.T) {
+	fileMatch := func(repo, path string, lines int, symbols ...string) map[string]interface{} {
+		var syms []interface{}
+		for _, s := range symbols {
+			syms = append(syms, map[string]interface{}{"name": s, "kind": "FUNCTION"})
+		}
+		return map[string]interface{}{
+			"__typename":  "FileMatch",
+			"repository":  map[string]interface{}{"name": repo},
+			"file":        map[string]interface{}{"path": path},
+			"lineMatches": make([]interface{}, lines),
+			"symbols":     syms,
+		}
+	}
+	results := []map[string]interface{}{
+		fileMatch("github.com/a/a", "a.go", 1, "Handle"),
+		fileMatch("github.com/b/b", "b.go", 3, "Handle", "Serve"),
+		{"__typename": "Repository", "name": "github.com/c/c"},
+		fileMatch("github.com/a/a", "a.go", 1),
+	}
+
+	if diff := cmp.Diff([]searchDedupGroup{
+		{Repository: "github.com/b/b", Path: "b.go", Count: 3},
+		{Repository: "github.com/a/a", Path: "a.go", Count: 2},
+	}, dedupSearchResults(results, searchDedupFile)); diff != "" {
+		t.Errorf("unexpected file groups (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]searchDedupGroup{
+		{Symbol: "Handle", Kind: "FUNCTION", Count: 2, Files: []string{"github.com/a/a/a.go", "github.com/b/b/b.go"}},
+		{Symbol: "Serve", Kind: "FUNCTION", Count: 1, Files: []string{"github.com/b/b/b.go"}},
+	}, dedupSearchResults(results, searchDedupSymbol)); diff != "" {
+		t.Errorf("unexpected symbol groups (-want +got):\n%s", diff)
+	}
+}
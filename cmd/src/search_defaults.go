@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/grafana/regexp"
+)
+
+// searchDefaults are the defaults applied to every 'src search' query, unless
+// -no-defaults is given. They are read from the searchDefaults key of the src
+// config file.
+type searchDefaults struct {
+	// QueryPrefix is prepended to the query, e.g. to scope searches to an
+	// organization's repositories.
+	QueryPrefix string `json:"queryPrefix,omitempty"`
+
+	// PatternType is added as a patternType: parameter if the query does not
+	// already specify one.
+	PatternType string `json:"patternType,omitempty"`
+
+	// Timeout is added as a timeout: parameter if the query does not already
+	// specify one.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+var (
+	patternTypeParameterPattern = regexp.MustCompile(`(?i)(^|\s)patternType:`)
+	timeoutParameterPattern     = regexp.MustCompile(`(?i)(^|\s)timeout:`)
+)
+
+// applySearchDefaults returns the query with the defaults applied. Parameters
+// the query already specifies, matched case-insensitively, take precedence
+// over the defaults.
+func applySearchDefaults(query string, defaults searchDefaults) string {
+	var parts []string
+	if defaults.QueryPrefix != "" {
+		parts = append(parts, defaults.QueryPrefix)
+	}
+	if query != "" {
+		parts = append(parts, query)
+	}
+	if defaults.PatternType != "" && !patternTypeParameterPattern.MatchString(query) {
+		parts = append(parts, "patternType:"+defaults.PatternType)
+	}
+	if defaults.Timeout != "" && !timeoutParameterPattern.MatchString(query) {
+		parts = append(parts, "timeout:"+defaults.Timeout)
+	}
+	return strings.Join(parts, " ")
+}
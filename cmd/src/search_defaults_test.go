@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestApplySearchDefaults(t *testing.T) {
+	defaults := searchDefaults{
+		QueryPrefix: `repo:^github\.com/acme/`,
+		PatternType: "regexp",
+		Timeout:     "30s",
+	}
+	for name, tc := range map[string]struct {
+		query    string
+		defaults searchDefaults
+		want     string
+	}{
+		"no defaults": {
+			query: "error",
+			want:  "error",
+		},
+		"all defaults": {
+			query:    "error",
+			defaults: defaults,
+			want:     `repo:^github\.com/acme/ error patternType:regexp timeout:30s`,
+		},
+		"query overrides": {
+			query:    "error PatternType:literal timeout:1m",
+			defaults: defaults,
+			want:     `repo:^github\.com/acme/ error PatternType:literal timeout:1m`,
+		},
+		"empty query": {
+			defaults: searchDefaults{QueryPrefix: "repo:acme"},
+			want:     "repo:acme",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := applySearchDefaults(tc.query, tc.defaults); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+)
+
+// searchResultRepoName returns the name of the repository the search result
+// belongs to.
+func searchResultRepoName(result map[string]interface{}) string {
+	switch result["__typename"] {
+	case "FileMatch":
+		if repo, ok := result["repository"].(map[string]interface{}); ok {
+			name, _ := repo["name"].(string)
+			return name
+		}
+	case "CommitSearchResult":
+		if commit, ok := result["commit"].(map[string]interface{}); ok {
+			if repo, ok := commit["repository"].(map[string]interface{}); ok {
+				name, _ := repo["name"].(string)
+				return name
+			}
+		}
+	case "Repository":
+		name, _ := result["name"].(string)
+		return name
+	}
+	return ""
+}
+
+// searchResultMatchCount returns the number of matches in the search result:
+// the number of matching lines for file matches, and 1 for other results.
+func searchResultMatchCount(result map[string]interface{}) int {
+	if result["__typename"] == "FileMatch" {
+		if lineMatches, ok := result["lineMatches"].([]interface{}); ok {
+			return len(lineMatches)
+		}
+	}
+	return 1
+}
+
+// groupSearchResultsByRepo groups the results by repository. Repositories are
+// ordered by their first result, and results keep their relative order.
+func groupSearchResultsByRepo(results []map[string]interface{}) (repos []string, groups map[string][]map[string]interface{}) {
+	groups = map[string][]map[string]interface{}{}
+	for _, r := range results {
+		name := searchResultRepoName(r)
+		if _, ok := groups[name]; !ok {
+			repos = append(repos, name)
+		}
+		groups[name] = append(groups[name], r)
+	}
+	return repos, groups
+}
+
+// searchRepoGroupHeader returns the header to render before the result at index
+// if it is the first result of its repository, and an empty string otherwise. The
+// results are expected to be grouped with groupSearchResultsByRepo.
+func searchRepoGroupHeader(results []map[string]interface{}, index int) string {
+	name := searchResultRepoName(results[index])
+	if index > 0 && searchResultRepoName(results[index-1]) == name {
+		return ""
+	}
+
+	var count, matches int
+	for _, r := range results[index:] {
+		if searchResultRepoName(r) != name {
+			break
+		}
+		count++
+		matches += searchResultMatchCount(r)
+	}
+	return fmt.Sprintf("%s%s%s%s (%d results, %d matches)%s\n",
+		ansiColors["search-repository"], name, ansiColors["nc"],
+		ansiColors["success"], count, matches, ansiColors["nc"])
+}
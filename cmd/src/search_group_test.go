@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGroupSearchResultsByRepo(t *testing.T) {
+	fileMatch := func(repo, path string, lines int) map[string]interface{} {
+		return map[string]interface{}{
+			"__typename":  "FileMatch",
+			"repository":  map[string]interface{}{"name": repo},
+			"file":        map[string]interface{}{"path": path},
+			"lineMatches": make([]interface{}, lines),
+		}
+	}
+	commit := map[string]interface{}{
+		"__typename": "CommitSearchResult",
+		"commit":     map[string]interface{}{"repository": map[string]interface{}{"name": "github.com/a/a"}},
+	}
+	repo := map[string]interface{}{"__typename": "Repository", "name": "github.com/c/c"}
+
+	results := []map[string]interface{}{
+		fileMatch("github.com/a/a", "a.go", 2),
+		fileMatch("github.com/b/b", "b.go", 1),
+		commit,
+		repo,
+		fileMatch("github.com/a/a", "c.go", 3),
+	}
+
+	repos, groups := groupSearchResultsByRepo(results)
+	if diff := cmp.Diff([]string{"github.com/a/a", "github.com/b/b", "github.com/c/c"}, repos); diff != "" {
+		t.Errorf("unexpected repos (-want +got):\n%s", diff)
+	}
+	wantGroups := map[string][]map[string]interface{}{
+		"github.com/a/a": {results[0], commit, results[4]},
+		"github.com/b/b": {results[1]},
+		"github.com/c/c": {repo},
+	}
+	if diff := cmp.Diff(wantGroups, groups); diff != "" {
+		t.Errorf("unexpected groups (-want +got):\n%s", diff)
+	}
+
+	var grouped []map[string]interface{}
+	for _, r := range repos {
+		grouped = append(grouped, groups[r]...)
+	}
+	var headers []string
+	for i := range grouped {
+		if header := searchRepoGroupHeader(grouped, i); header != "" {
+			headers = append(headers, header)
+		}
+	}
+	wantHeaders := []string{
+		"github.com/a/a (3 results, 6 matches)",
+		"github.com/b/b (1 results, 1 matches)",
+		"github.com/c/c (1 results, 1 matches)",
+	}
+	if len(headers) != len(wantHeaders) {
+		t.Fatalf("want %d headers, got %d: %q", len(wantHeaders), len(headers), headers)
+	}
+	for i, want := range wantHeaders {
+		if got := ansiRegexp.ReplaceAllString(headers[i], ""); strings.TrimSpace(got) != want {
+			t.Errorf("unexpected header %d: want %q, got %q", i, want, got)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sourcegraph/src-cli/internal/version"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// sarifLog is the subset of the SARIF 2.1.0 format written by 'src search -sarif'.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID          string          `json:"ruleId"`
+	Level           string          `json:"level"`
+	Message         sarifMessage    `json:"message"`
+	Locations       []sarifLocation `json:"locations"`
+	HostedViewerURI string          `json:"hostedViewerUri,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// sarifRegion is a range on a single line. Lines and columns are 1-based, and
+// EndColumn is exclusive.
+type sarifRegion struct {
+	StartLine   int          `json:"startLine"`
+	StartColumn int          `json:"startColumn"`
+	EndColumn   int          `json:"endColumn"`
+	Snippet     sarifMessage `json:"snippet"`
+}
+
+// searchResultsSARIF converts the matched ranges in the file matches of the
+// results into SARIF results, one per range, with the query as their rule. The
+// path of each file is relative to its repository, whose name is used as the
+// uriBaseId. Other results, such as commits and repositories, are ignored.
+func searchResultsSARIF(results searchResultsImproved) sarifLog {
+	rule := sarifRule{ID: results.Query, ShortDescription: sarifMessage{Text: results.Query}}
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "src",
+			Version:        version.BuildTag,
+			InformationURI: "https://github.com/sourcegraph/src-cli",
+			Rules:          []sarifRule{rule},
+		}},
+		Results: []sarifResult{},
+	}
+
+	for _, r := range results.Results {
+		if r["__typename"] != "FileMatch" {
+			continue
+		}
+		repo := searchResultRepoName(r)
+		var path, url string
+		if file, ok := r["file"].(map[string]interface{}); ok {
+			path, _ = file["path"].(string)
+			url, _ = file["url"].(string)
+		}
+		lineMatches, _ := r["lineMatches"].([]interface{})
+		for _, lm := range lineMatches {
+			lm, ok := lm.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			// Line numbers in search results are 0-based.
+			lineNumber, _ := lm["lineNumber"].(float64)
+			line := int(lineNumber) + 1
+			preview, _ := lm["preview"].(string)
+			ranges, _ := lm["offsetAndLengths"].([]interface{})
+			for _, rg := range ranges {
+				rg, ok := rg.([]interface{})
+				if !ok || len(rg) != 2 {
+					continue
+				}
+				offset, _ := rg[0].(float64)
+				length, _ := rg[1].(float64)
+
+				result := sarifResult{
+					RuleID:  rule.ID,
+					Level:   "warning",
+					Message: sarifMessage{Text: fmt.Sprintf("Match for %q in %s/%s:%d", results.Query, repo, path, line)},
+					Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: path, URIBaseID: repo},
+						Region: sarifRegion{
+							StartLine:   line,
+							StartColumn: int(offset) + 1,
+							EndColumn:   int(offset+length) + 1,
+							Snippet:     sarifMessage{Text: preview},
+						},
+					}}},
+				}
+				if url != "" {
+					result.HostedViewerURI = fmt.Sprintf("%s%s?L%d", results.SourcegraphEndpoint, url, line)
+				}
+				run.Results = append(run.Results, result)
+			}
+		}
+	}
+
+	return sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}
+}
+
+// writeSearchResultsSARIF writes the results to w as a SARIF log, and returns the
+// number of SARIF results written.
+func writeSearchResultsSARIF(w io.Writer, results searchResultsImproved) (int, error) {
+	log := searchResultsSARIF(results)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return len(log.Runs[0].Results), enc.Encode(log)
+}
+
+// writeSearchSARIFFile writes the results as a SARIF log to the named file, or to
+// standard output if file is "-", and reports the number of results on status.
+func writeSearchSARIFFile(file string, results searchResultsImproved, status io.Writer) error {
+	if file == "-" {
+		_, err := writeSearchResultsSARIF(os.Stdout, results)
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	n, err := writeSearchResultsSARIF(f, results)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(status, "%d results written to %s\n", n, file)
+	return nil
+}
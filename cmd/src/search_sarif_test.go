@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSearchResultsSARIF(t *testing.T) {
+	results := searchResultsImproved{
+		SourcegraphEndpoint: "https://sourcegraph.example.com",
+		Query:               `md5\.New\(`,
+		searchResults: searchResults{Results: []map[string]interface{}{
+			{
+				"__typename": "FileMatch",
+				"repository": map[string]interface{}{"name": "github.com/acme/app"},
+				"file": map[string]interface{}{
+					"path": "internal/hash.go",
+					"url":  "/github.com/acme/app/-/blob/internal/hash.go",
+				},
+				"lineMatches": []interface{}{
+					map[string]interface{}{
+						"preview":          "	h := md5.New(); g := md5.New()",
+						"lineNumber":       float64(9),
+						"offsetAndLengths": []interface{}{[]interface{}{float64(6), float64(8)}, []interface{}{float64(22), float64(8)}},
+					},
+				},
+			},
+			{"__typename": "Repository", "name": "github.com/acme/app"},
+		}},
+	}
+
+	log := searchResultsSARIF(results)
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+	run := log.Runs[0]
+	if diff := cmp.Diff([]sarifRule{{ID: `md5\.New\(`, ShortDescription: sarifMessage{Text: `md5\.New\(`}}}, run.Tool.Driver.Rules); diff != "" {
+		t.Errorf("unexpected rules (-want +got):\n%s", diff)
+	}
+
+	location := func(startColumn, endColumn int) []sarifLocation {
+		return []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: "internal/hash.go", URIBaseID: "github.com/acme/app"},
+			Region: sarifRegion{
+				StartLine:   10,
+				StartColumn: startColumn,
+				EndColumn:   endColumn,
+				Snippet:     sarifMessage{Text: "	h := md5.New(); g := md5.New()"},
+			},
+		}}}
+	}
+	message := sarifMessage{Text: `Match for "md5\\.New\\(" in github.com/acme/app/internal/hash.go:10`}
+	viewer := "https://sourcegraph.example.com/github.com/acme/app/-/blob/internal/hash.go?L10"
+	if diff := cmp.Diff([]sarifResult{
+		{RuleID: `md5\.New\(`, Level: "warning", Message: message, Locations: location(7, 15), HostedViewerURI: viewer},
+		{RuleID: `md5\.New\(`, Level: "warning", Message: message, Locations: location(23, 31), HostedViewerURI: viewer},
+	}, run.Results); diff != "" {
+		t.Errorf("unexpected results (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/src-cli/internal/cmderrors"
+)
+
+// searchSavedCommands contains the 'src search saved' subcommands. They are
+// dispatched from the search handler, since 'src search' is not a commander.
+var searchSavedCommands commander
+
+const searchSavedUsage = `'src search saved' manages search queries saved for reuse with 'src search -saved=name'.
+
+Saved queries are stored in the src config file (by default ~/src-config.json, or the
+file given with -config). They are namespaced by the Sourcegraph endpoint, so a query
+saved against one instance is not available when targeting another.
+
+Usage:
+
+	src search saved command [command options]
+
+The commands are:
+
+	add       saves a search query under a name
+	list      lists the saved search queries for the current endpoint
+	remove    removes a saved search query
+
+Use "src search saved [command] -h" for more information about a command.
+`
+
+var searchSavedFlagSet = flag.NewFlagSet("saved", flag.ExitOnError)
+
+func init() {
+	addUsage := `
+Examples:
+
+  Save a search query:
+
+    	$ src search saved add todos 'repo:^github\.com/acme/ TODO'
+
+  Run it:
+
+    	$ src search -saved=todos
+`
+
+	addFlagSet := flag.NewFlagSet("add", flag.ExitOnError)
+	forceFlag := addFlagSet.Bool("force", false, "Overwrite an existing saved query with the same name.")
+	searchSavedCommands = append(searchSavedCommands, &command{
+		flagSet: addFlagSet,
+		handler: func(args []string) error {
+			if err := addFlagSet.Parse(args); err != nil {
+				return err
+			}
+			if addFlagSet.NArg() != 2 {
+				return cmderrors.Usage("expected exactly two arguments: the name and the search query")
+			}
+			name, query := addFlagSet.Arg(0), addFlagSet.Arg(1)
+
+			searches := cfg.savedSearches()
+			if _, ok := searches[name]; ok && !*forceFlag {
+				return errors.Newf("a saved search named %q already exists for %s; use -force to overwrite it", name, cfg.Endpoint)
+			}
+			searches[name] = query
+			if err := cfg.writeSavedSearches(searches); err != nil {
+				return err
+			}
+			fmt.Printf("Saved search %q for %s.\n", name, cfg.Endpoint)
+			return nil
+		},
+		usageFunc: func() {
+			fmt.Fprintf(flag.CommandLine.Output(), "Usage of 'src search saved %s':\n", addFlagSet.Name())
+			addFlagSet.PrintDefaults()
+			fmt.Println(addUsage)
+		},
+	})
+
+	listFlagSet := flag.NewFlagSet("list", flag.ExitOnError)
+	formatFlag := listFlagSet.String("f", "{{.Name}}: {{.Query}}", `Format for the output, using the syntax of Go package text/template. (e.g. "{{.Name}}" or "{{.|json}}")`)
+	searchSavedCommands = append(searchSavedCommands, &command{
+		flagSet: listFlagSet,
+		handler: func(args []string) error {
+			if err := listFlagSet.Parse(args); err != nil {
+				return err
+			}
+			tmpl, err := parseTemplate(*formatFlag)
+			if err != nil {
+				return err
+			}
+
+			searches := cfg.savedSearches()
+			names := make([]string, 0, len(searches))
+			for name := range searches {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				if err := execTemplate(tmpl, struct{ Name, Query string }{name, searches[name]}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+
+	removeFlagSet := flag.NewFlagSet("remove", flag.ExitOnError)
+	searchSavedCommands = append(searchSavedCommands, &command{
+		flagSet: removeFlagSet,
+		aliases: []string{"rm"},
+		handler: func(args []string) error {
+			if err := removeFlagSet.Parse(args); err != nil {
+				return err
+			}
+			if removeFlagSet.NArg() != 1 {
+				return cmderrors.Usage("expected exactly one argument: the name of the saved search")
+			}
+			name := removeFlagSet.Arg(0)
+
+			searches := cfg.savedSearches()
+			if _, ok := searches[name]; !ok {
+				return errors.Newf("no saved search named %q for %s", name, cfg.Endpoint)
+			}
+			delete(searches, name)
+			if err := cfg.writeSavedSearches(searches); err != nil {
+				return err
+			}
+			fmt.Printf("Removed saved search %q for %s.\n", name, cfg.Endpoint)
+			return nil
+		},
+	})
+}
+
+// savedSearches returns a copy of the saved searches for the configured
+// endpoint.
+func (c *config) savedSearches() map[string]string {
+	searches := map[string]string{}
+	for name, query := range c.SavedSearches[c.Endpoint] {
+		searches[name] = query
+	}
+	return searches
+}
+
+// writeSavedSearches replaces the saved searches for the configured endpoint
+// and persists them to the config file.
+func (c *config) writeSavedSearches(searches map[string]string) error {
+	all := map[string]map[string]string{}
+	for endpoint, s := range c.SavedSearches {
+		all[endpoint] = s
+	}
+	if len(searches) == 0 {
+		delete(all, c.Endpoint)
+	} else {
+		all[c.Endpoint] = searches
+	}
+
+	if err := updateConfigFile("savedSearches", all); err != nil {
+		return errors.Wrap(err, "writing saved searches")
+	}
+	c.SavedSearches = all
+	return nil
+}
+
+// resolveSavedSearch returns the saved query with the given name, with any
+// additional query terms appended.
+func resolveSavedSearch(name string, extra []string) (string, error) {
+	query, ok := cfg.SavedSearches[cfg.Endpoint][name]
+	if !ok {
+		return "", errors.Newf("no saved search named %q for %s; see 'src search saved list'", name, cfg.Endpoint)
+	}
+	for _, e := range extra {
+		query += " " + e
+	}
+	return query, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSavedSearches(t *testing.T) {
+	tmpDir := t.TempDir()
+	testHomeDir = tmpDir
+	t.Cleanup(func() { testHomeDir = "" })
+
+	cfgPath := filepath.Join(tmpDir, "src-config.json")
+	if err := os.WriteFile(cfgPath, []byte(`{"endpoint": "https://example.com", "accessToken": "deadbeef"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	oldCfg := cfg
+	t.Cleanup(func() { cfg = oldCfg })
+
+	for _, endpoint := range []string{"https://example.com", "https://other.example.com"} {
+		var err error
+		if cfg, err = readConfig(); err != nil {
+			t.Fatal(err)
+		}
+		cfg.Endpoint = endpoint
+
+		searches := cfg.savedSearches()
+		searches["todos"] = "TODO " + endpoint
+		if err := cfg.writeSavedSearches(searches); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("namespaced by endpoint", func(t *testing.T) {
+		cfg.Endpoint = "https://example.com"
+		query, err := resolveSavedSearch("todos", []string{"lang:go"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "TODO https://example.com lang:go"; query != want {
+			t.Errorf("unexpected query: want %q, got %q", want, query)
+		}
+
+		cfg.Endpoint = "https://unknown.example.com"
+		if _, err := resolveSavedSearch("todos", nil); err == nil {
+			t.Error("expected error resolving saved search for unknown endpoint")
+		}
+	})
+
+	t.Run("other config preserved", func(t *testing.T) {
+		data, err := os.ReadFile(cfgPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]interface{}{
+			"endpoint":    "https://example.com",
+			"accessToken": "deadbeef",
+			"savedSearches": map[string]interface{}{
+				"https://example.com":       map[string]interface{}{"todos": "TODO https://example.com"},
+				"https://other.example.com": map[string]interface{}{"todos": "TODO https://other.example.com"},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected config file contents (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -131,3 +131,15 @@ func TestBuildVersionHasNewSearchInterface(t *testing.T) {
 		t.Errorf("Build version is after the new generic search interface was merged. Expected true, but got false.")
 	}
 }
+
+func TestApplyHighlights(t *testing.T) {
+	highlights := []highlight{{line: 1, character: 4, length: 3}}
+	if got, want := applyHighlights("foo bar baz", highlights, "<", ">"), "foo <bar> baz\n"; got != want {
+		t.Errorf("unexpected highlighted output: want %q, got %q", want, got)
+	}
+
+	// Highlighting is disabled by an empty start sequence, as with -no-highlight.
+	if got, want := applyHighlights("foo bar baz", highlights, "", ">"), "foo bar baz\n"; got != want {
+		t.Errorf("unexpected output without highlighting: want %q, got %q", want, got)
+	}
+}
@@ -6,6 +6,10 @@ import (
 	"io"
 	"log"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
 
 	"github.com/sourcegraph/src-cli/internal/cmderrors"
 	"github.com/sourcegraph/src-cli/internal/servegit"
@@ -17,19 +21,34 @@ func init() {
 		fmt.Fprintf(flag.CommandLine.Output(), `'src serve-git' serves your local git repositories over HTTP for Sourcegraph to pull.
 
 USAGE
-  src [-v] serve-git [-list] [-addr :3434] [path/to/dir]
+  src [-v] serve-git [-list] [-addr :3434] [-username user -password pass] [-watch] [path/to/dir]
 
 By default 'src serve-git' will recursively serve your current directory on the address ':3434'.
 
 'src serve-git -list' will not start up the server. Instead it will write to stdout a list of
 repository names it would serve.
 
+'src serve-git -username=user -password-file=path/to/file' will require clients to authenticate
+with HTTP basic auth. Requests without valid credentials are rejected with 401 Unauthorized. This
+is useful to test Sourcegraph's authenticated cloning.
+
+'src serve-git -watch' re-scans the directory every -watch-interval, so that repositories cloned
+into it or removed from it are picked up without restarting. The directory is polled rather than
+watched for filesystem events, which are not reliably reported for nested directories or network
+filesystems. Only repositories with a commit checked out
+are served, so that repositories that are still being cloned are not served.
+
 Documentation at https://docs.sourcegraph.com/admin/external_service/src_serve_git
 `)
 	}
 	var (
-		addrFlag = flagSet.String("addr", ":3434", "Address on which to serve (end with : for unused port)")
-		listFlag = flagSet.Bool("list", false, "list found repository names")
+		addrFlag          = flagSet.String("addr", ":3434", "Address on which to serve (end with : for unused port)")
+		listFlag          = flagSet.Bool("list", false, "list found repository names")
+		usernameFlag      = flagSet.String("username", "", "Username clients must provide via HTTP basic auth")
+		passwordFlag      = flagSet.String("password", "", "Password clients must provide via HTTP basic auth")
+		passwordFileFlag  = flagSet.String("password-file", "", "File containing the password or token clients must provide via HTTP basic auth")
+		watchFlag         = flagSet.Bool("watch", false, "Re-scan the directory for added or removed repositories while serving. The directory is polled every -watch-interval rather than watched for filesystem events")
+		watchIntervalFlag = flagSet.Duration("watch-interval", 2*time.Second, "How often to re-scan the directory with -watch")
 	)
 
 	handler := func(args []string) error {
@@ -53,6 +72,21 @@ Documentation at https://docs.sourcegraph.com/admin/external_service/src_serve_g
 			return cmderrors.Usage("requires zero or one arguments")
 		}
 
+		password := *passwordFlag
+		if *passwordFileFlag != "" {
+			if password != "" {
+				return cmderrors.Usage("only one of -password and -password-file may be given")
+			}
+			b, err := os.ReadFile(*passwordFileFlag)
+			if err != nil {
+				return errors.Wrap(err, "reading password file")
+			}
+			password = strings.TrimSpace(string(b))
+		}
+		if (*usernameFlag == "") != (password == "") {
+			return cmderrors.Usage("-username and -password (or -password-file) must be given together")
+		}
+
 		dbug := log.New(io.Discard, "", log.LstdFlags)
 		if *verbose {
 			dbug = log.New(os.Stderr, "DBUG serve-git: ", log.LstdFlags)
@@ -63,6 +97,15 @@ Documentation at https://docs.sourcegraph.com/admin/external_service/src_serve_g
 			Root:  repoDir,
 			Info:  log.New(os.Stderr, "serve-git: ", log.LstdFlags),
 			Debug: dbug,
+
+			Username: *usernameFlag,
+			Password: password,
+		}
+		if *watchFlag {
+			if *watchIntervalFlag <= 0 {
+				return cmderrors.Usage("-watch-interval must be positive")
+			}
+			s.WatchInterval = *watchIntervalFlag
 		}
 
 		if *listFlag {
@@ -15,8 +15,11 @@ USAGE
 
 COMMANDS
 
+	archive   bundle the snapshot directory and a manifest with checksums into a single compressed archive
+	dumps     report the sizes of database dumps in the snapshot directory, flagging missing or empty dumps
 	summary   export summary data about an instance for acceptance testing of a restored Sourcegraph instance
 	test      use exported summary data and instance health indicators to validate a restored and upgraded instance
+	verify    validate the checksums of an archive generated with 'src snapshot archive'
 `
 	flagSet := flag.NewFlagSet("snapshot", flag.ExitOnError)
 
@@ -26,6 +29,7 @@ COMMANDS
 			snapshotCommands.run(flagSet, "src snapshot", usage, args)
 			return nil
 		},
-		usageFunc: func() { fmt.Fprint(flag.CommandLine.Output(), usage) },
+		subcommands: &snapshotCommands,
+		usageFunc:   func() { fmt.Fprint(flag.CommandLine.Output(), usage) },
 	})
 }
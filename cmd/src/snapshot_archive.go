@@ -0,0 +1,396 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"github.com/sourcegraph/sourcegraph/lib/output"
+
+	"github.com/sourcegraph/src-cli/internal/api"
+	"github.com/sourcegraph/src-cli/internal/pgdump"
+	"github.com/sourcegraph/src-cli/internal/version"
+)
+
+const snapshotManifestName = "manifest.json"
+
+func init() {
+	usage := fmt.Sprintf(`'src snapshot archive' bundles the contents of the snapshot directory %q into a single compressed archive for transfer.
+
+The archive contains a %s recording the source endpoint, the Sourcegraph and src versions, the time the archive was created, and the SHA-256 checksum of every file. Use 'src snapshot verify' to validate an archive against its manifest.
+
+USAGE
+	src [-v] snapshot archive [-o snapshot.tar.gz]
+`, srcSnapshotDir, snapshotManifestName)
+	flagSet := flag.NewFlagSet("archive", flag.ExitOnError)
+	outputFlag := flagSet.String("o", "src-snapshot.tar.gz", "The path of the archive to write.")
+	apiFlags := api.NewFlags(flagSet)
+
+	snapshotCommands = append(snapshotCommands, &command{
+		flagSet: flagSet,
+		handler: func(args []string) error {
+			if err := flagSet.Parse(args); err != nil {
+				return err
+			}
+			out := output.NewOutput(statusWriter(flagSet.Output()), output.OutputOpts{Verbose: *verbose})
+
+			manifest := snapshotManifest{
+				Endpoint:   cfg.Endpoint,
+				SrcVersion: version.BuildTag,
+				CreatedAt:  time.Now().UTC(),
+			}
+			client := cfg.apiClient(apiFlags, flagSet.Output())
+			productVersion, err := fetchSnapshotProductVersion(context.Background(), client)
+			if err != nil {
+				out.WriteLine(output.Linef(output.EmojiWarning, output.StyleWarning, "Could not get the Sourcegraph version of %s, it is omitted from the manifest: %s", cfg.Endpoint, err))
+			}
+			manifest.SourcegraphVersion = productVersion
+
+			f, err := os.Create(*outputFlag)
+			if err != nil {
+				return errors.Wrap(err, "create archive")
+			}
+			defer f.Close()
+
+			manifest, err = writeSnapshotArchive(f, srcSnapshotDir, manifest, *outputFlag)
+			if err != nil {
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return errors.Wrap(err, "write archive")
+			}
+
+			out.WriteLine(output.Emojif(output.EmojiSuccess, "Archived %d files from %q in %q!", len(manifest.Files), srcSnapshotDir, *outputFlag))
+			return nil
+		},
+		usageFunc: func() { fmt.Fprint(flag.CommandLine.Output(), usage) },
+	})
+}
+
+func init() {
+	usage := fmt.Sprintf(`'src snapshot verify' validates an archive generated with 'src snapshot archive' against its %s.
+
+Every file listed in the manifest must be present in the archive with a matching SHA-256 checksum, and the archive must not contain files that are not listed. The command exits with an error otherwise.
+
+USAGE
+	src [-v] snapshot verify [-json] snapshot.tar.gz
+`, snapshotManifestName)
+	flagSet := flag.NewFlagSet("verify", flag.ExitOnError)
+	jsonFlag := flagSet.Bool("json", false, "Print the report as JSON.")
+
+	snapshotCommands = append(snapshotCommands, &command{
+		flagSet: flagSet,
+		handler: func(args []string) error {
+			if err := flagSet.Parse(args); err != nil {
+				return err
+			}
+			if flagSet.NArg() != 1 {
+				return errors.New("expected exactly one argument: the archive to verify")
+			}
+			out := output.NewOutput(statusWriter(flagSet.Output()), output.OutputOpts{Verbose: *verbose})
+
+			f, err := os.Open(flagSet.Arg(0))
+			if err != nil {
+				return errors.Wrap(err, "open archive")
+			}
+			defer f.Close()
+
+			manifest, files, err := verifySnapshotArchive(f)
+			if err != nil {
+				return err
+			}
+
+			var problems int
+			for _, f := range files {
+				if f.Status != snapshotDumpOK {
+					problems++
+				}
+			}
+
+			if *jsonFlag {
+				data, err := marshalIndent(struct {
+					Manifest snapshotManifest              `json:"manifest"`
+					Files    []snapshotArchiveVerification `json:"files"`
+				}{manifest, files})
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(data))
+			} else {
+				out.Writef("Snapshot of %s (Sourcegraph %s) created at %s with src %s",
+					manifest.Endpoint, manifest.SourcegraphVersion, manifest.CreatedAt.Format(time.RFC3339), manifest.SrcVersion)
+				t := table.NewWriter()
+				t.SetOutputMirror(os.Stdout)
+				t.AppendHeader(table.Row{"File", "Database", "Status"})
+				for _, f := range files {
+					t.AppendRow(table.Row{f.Path, f.Database, f.Status})
+				}
+				t.SetStyle(table.StyleRounded)
+				t.Render()
+			}
+
+			if problems > 0 {
+				out.WriteLine(output.Linef(output.EmojiFailure, output.StyleFailure, "%d files in the archive do not match the manifest", problems))
+				return errors.Newf("%d files do not match the manifest", problems)
+			}
+			return nil
+		},
+		usageFunc: func() { fmt.Fprint(flag.CommandLine.Output(), usage) },
+	})
+}
+
+// snapshotManifest describes the contents of a snapshot archive.
+type snapshotManifest struct {
+	Endpoint           string                 `json:"endpoint"`
+	SourcegraphVersion string                 `json:"sourcegraphVersion,omitempty"`
+	SrcVersion         string                 `json:"srcVersion"`
+	CreatedAt          time.Time              `json:"createdAt"`
+	Files              []snapshotManifestFile `json:"files"`
+}
+
+// snapshotManifestFile is a file in a snapshot archive.
+type snapshotManifestFile struct {
+	// Path is the path of the file in the archive, relative to the snapshot
+	// directory.
+	Path string `json:"path"`
+	// Database is set if the file is the dump of a database.
+	Database string `json:"database,omitempty"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+}
+
+const (
+	snapshotArchiveMismatch   = "checksum mismatch"
+	snapshotArchiveUnexpected = "not in manifest"
+)
+
+// snapshotArchiveVerification is the result of verifying a file of a snapshot
+// archive. The status is one of snapshotDumpOK, snapshotDumpMissing,
+// snapshotArchiveMismatch, or snapshotArchiveUnexpected.
+type snapshotArchiveVerification struct {
+	Path     string `json:"path"`
+	Database string `json:"database,omitempty"`
+	Status   string `json:"status"`
+}
+
+// fetchSnapshotProductVersion returns the version of the Sourcegraph instance.
+func fetchSnapshotProductVersion(ctx context.Context, client api.Client) (string, error) {
+	var result struct {
+		Site struct {
+			ProductVersion string
+		}
+	}
+	if ok, err := client.NewQuery(`query { site { productVersion } }`).Do(ctx, &result); err != nil || !ok {
+		return "", err
+	}
+	return result.Site.ProductVersion, nil
+}
+
+// writeSnapshotArchive writes a gzipped tarball of the files in dir and the
+// manifest describing them to w, and returns the manifest. The file at the
+// exclude path is skipped, so that an archive can be written to dir.
+func writeSnapshotArchive(w io.Writer, dir string, manifest snapshotManifest, exclude string) (snapshotManifest, error) {
+	databases := map[string]string{}
+	for _, o := range pgdump.Outputs(dir, pgdump.Targets{}) {
+		databases[filepath.Clean(o.Output)] = o.Database
+	}
+
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || sameFile(path, exclude) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		// Dumps generated with 'src snapshot databases --compress' are gzipped.
+		dump := strings.TrimSuffix(filepath.Clean(path), pgdump.CompressedExtension)
+		sum, size, err := sha256File(path)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, snapshotManifestFile{
+			Path:     filepath.ToSlash(rel),
+			Database: databases[dump],
+			Size:     size,
+			SHA256:   sum,
+		})
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return manifest, errors.Wrapf(err, "reading snapshot directory %q", dir)
+	}
+	if len(paths) == 0 {
+		return manifest, errors.Newf("snapshot directory %q is empty - generate a snapshot with 'src snapshot databases' and 'src snapshot summary'", dir)
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	data, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return manifest, err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    snapshotManifestName,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: manifest.CreatedAt,
+	}); err != nil {
+		return manifest, err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return manifest, err
+	}
+
+	for i, path := range paths {
+		if err := addSnapshotArchiveFile(tw, path, manifest.Files[i]); err != nil {
+			return manifest, errors.Wrapf(err, "archiving %q", path)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return manifest, err
+	}
+	return manifest, gw.Close()
+}
+
+func addSnapshotArchiveFile(tw *tar.Writer, path string, file snapshotManifestFile) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() != file.Size {
+		return errors.New("file changed while archiving")
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    file.Path,
+		Mode:    0644,
+		Size:    file.Size,
+		ModTime: info.ModTime(),
+	}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// verifySnapshotArchive reads a gzipped tarball written by writeSnapshotArchive,
+// and checks its files against the manifest.
+func verifySnapshotArchive(r io.Reader) (snapshotManifest, []snapshotArchiveVerification, error) {
+	var manifest snapshotManifest
+
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return manifest, nil, errors.Wrap(err, "reading archive")
+	}
+	tr := tar.NewReader(gr)
+
+	var (
+		sums        = map[string]string{}
+		hasManifest bool
+	)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return manifest, nil, errors.Wrap(err, "reading archive")
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if header.Name == snapshotManifestName {
+			if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+				return manifest, nil, errors.Wrapf(err, "reading %s", snapshotManifestName)
+			}
+			hasManifest = true
+			continue
+		}
+
+		h := sha256.New()
+		if _, err := io.Copy(h, tr); err != nil {
+			return manifest, nil, errors.Wrapf(err, "reading %q", header.Name)
+		}
+		sums[header.Name] = hex.EncodeToString(h.Sum(nil))
+	}
+	if !hasManifest {
+		return manifest, nil, errors.Newf("archive has no %s", snapshotManifestName)
+	}
+
+	var files []snapshotArchiveVerification
+	for _, f := range manifest.Files {
+		v := snapshotArchiveVerification{Path: f.Path, Database: f.Database, Status: snapshotDumpOK}
+		sum, ok := sums[f.Path]
+		switch {
+		case !ok:
+			v.Status = snapshotDumpMissing
+		case sum != f.SHA256:
+			v.Status = snapshotArchiveMismatch
+		}
+		delete(sums, f.Path)
+		files = append(files, v)
+	}
+	for path := range sums {
+		files = append(files, snapshotArchiveVerification{Path: path, Status: snapshotArchiveUnexpected})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return manifest, files, nil
+}
+
+// sha256File returns the hex-encoded SHA-256 checksum and the size of the file.
+func sha256File(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// sameFile reports whether the paths refer to the same existing file.
+func sameFile(a, b string) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(ai, bi)
+}
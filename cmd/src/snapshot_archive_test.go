@@ -0,0 +1,132 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSnapshotArchive(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"primary.sql":  "CREATE TABLE repo;",
+		"summary.json": "{}",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	manifest, err := writeSnapshotArchive(&buf, dir, snapshotManifest{
+		Endpoint:  "https://sourcegraph.example.com",
+		CreatedAt: time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC),
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Files) != 2 || manifest.Files[0].Database != "primary" || manifest.Files[1].Database != "" {
+		t.Fatalf("unexpected manifest files: %+v", manifest.Files)
+	}
+
+	got, files, err := verifySnapshotArchive(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(manifest, got); diff != "" {
+		t.Errorf("unexpected manifest (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]snapshotArchiveVerification{
+		{Path: "primary.sql", Database: "primary", Status: snapshotDumpOK},
+		{Path: "summary.json", Status: snapshotDumpOK},
+	}, files); diff != "" {
+		t.Errorf("unexpected verification (-want +got):\n%s", diff)
+	}
+
+	// Rewrite the archive with a modified dump, a missing summary, and an
+	// unexpected file.
+	tampered := rewriteArchive(t, buf.Bytes(), map[string]string{
+		"primary.sql":  "DROP TABLE repo;",
+		"summary.json": "",
+		"extra.txt":    "surprise",
+	})
+	_, files, err = verifySnapshotArchive(bytes.NewReader(tampered))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]snapshotArchiveVerification{
+		{Path: "extra.txt", Status: snapshotArchiveUnexpected},
+		{Path: "primary.sql", Database: "primary", Status: snapshotArchiveMismatch},
+		{Path: "summary.json", Status: snapshotDumpMissing},
+	}, files); diff != "" {
+		t.Errorf("unexpected verification (-want +got):\n%s", diff)
+	}
+}
+
+// rewriteArchive copies the gzipped tarball, replacing the contents of the files
+// in replace. Files replaced with an empty string are dropped, and files not in
+// the archive are added.
+func rewriteArchive(t *testing.T, archive []byte, replace map[string]string) []byte {
+	t.Helper()
+
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	write := func(name string, content []byte) {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seen := map[string]bool{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[header.Name] = true
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r, ok := replace[header.Name]; ok {
+			if r == "" {
+				continue
+			}
+			content = []byte(r)
+		}
+		write(header.Name, content)
+	}
+	for name, content := range replace {
+		if !seen[name] {
+			write(name, []byte(content))
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
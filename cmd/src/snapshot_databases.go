@@ -8,8 +8,8 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 	"github.com/sourcegraph/sourcegraph/lib/output"
-	"gopkg.in/yaml.v3"
 
+	"github.com/sourcegraph/src-cli/internal/cmderrors"
 	"github.com/sourcegraph/src-cli/internal/pgdump"
 )
 
@@ -18,7 +18,34 @@ func init() {
 Note that these commands are intended for use as reference - you may need to adjust the commands for your deployment.
 
 USAGE
-	src [-v] snapshot databases <pg_dump|docker|kubectl> [--targets=<docker|k8s|"targets.yaml">]
+	src [-v] snapshot databases <pg_dump|direct|docker|kubectl> [--targets=<docker|k8s|"targets.yaml">] [--compress] [--mode=<full|schema-only|data-only>] [--only=<databases>|--skip=<databases>] [--parallel]
+
+BUILDERS
+	'pg_dump' runs pg_dump locally, connecting to the target host if one is configured.
+	'docker' and 'kubectl' run pg_dump inside the target container or deployment.
+	'direct' runs pg_dump locally against a database server reached over the network, such
+	as an AWS RDS or other managed Postgres instance. It requires a custom targets file in which
+	each database sets 'target' to the hostname of the server, and optionally 'port'.
+
+DATABASES
+	Commands are generated for the 'primary', 'codeintel', and 'codeinsights' databases. Use
+	'--only' or '--skip' with a comma-separated list of database names to select a subset, e.g.
+	'--only=codeinsights'. Databases that are not selected may be omitted from custom targets files.
+
+MODES
+	'--mode=schema-only' dumps only the schema, e.g. to bootstrap an empty environment, and
+	'--mode=data-only' dumps only the data, e.g. to seed databases that already have the schema.
+	The default, '--mode=full', dumps both.
+
+	Foreign key constraints may fail while a data-only dump is restored, because tables are
+	restored in an arbitrary order. Add '--disable-triggers' to the generated commands to avoid
+	this; the dumps must then be restored as a superuser.
+
+PARALLEL DUMPS
+	With '--parallel', the 'pg_dump' and 'direct' builders generate commands that run the dumps
+	concurrently, in the background of the shell they are pasted into, followed by 'wait'. This is
+	faster, but loads the database servers and the network with all the dumps at once. The 'docker'
+	and 'kubectl' builders ignore '--parallel'.
 
 TARGETS FILES
 	Predefined targets are available based on default Sourcegraph configurations ('docker', 'k8s').
@@ -26,78 +53,133 @@ TARGETS FILES
 
 		primary:
 			target: ...   # the DSN of the database deployment, e.g. in docker, the name of the database container
-			dbname: ...   # name of database
-			username: ... # username for database access
+			port: ...     # port of the database server, if not the default
+			dbname: ...   # name of database (required)
+			username: ... # username for database access (required)
 			password: ... # password for database access - only include password if it is non-sensitive
+			password_command: ... # command that prints the password, for the 'pg_dump' and 'direct' builders
 		codeintel:
 			# same as above
 		codeinsights:
 			# same as above
 
 	See the pgdump.Targets type for more details.
+
+IAM AUTHENTICATION
+	Databases that use IAM authentication, such as AWS RDS, can generate a short-lived password with
+	'password_command', which is run by the shell that runs each pg_dump command, e.g.
+
+		codeinsights:
+			target: codeinsights.abc123.us-east-1.rds.amazonaws.com
+			port: 5432
+			dbname: postgres
+			username: sourcegraph
+			password_command: aws rds generate-db-auth-token --hostname codeinsights.abc123.us-east-1.rds.amazonaws.com --port 5432 --username sourcegraph --region us-east-1
+
+	IAM authentication requires SSL, so set PGSSLMODE=require in the environment that runs the commands.
 `
 	flagSet := flag.NewFlagSet("databases", flag.ExitOnError)
 	targetsKeyFlag := flagSet.String("targets", "auto", "predefined targets ('docker' or 'k8s'), or a custom targets.yaml file")
+	compressFlag := flagSet.Bool("compress", false, "compress dumps with gzip before writing them to '.sql.gz' files")
+	modeFlag := flagSet.String("mode", string(pgdump.ModeFull), "parts of the databases to dump ('full', 'schema-only', or 'data-only')")
+	onlyFlag := flagSet.String("only", "", "comma-separated list of databases to generate commands for ('primary', 'codeintel', 'codeinsights')")
+	skipFlag := flagSet.String("skip", "", "comma-separated list of databases to omit commands for")
+	parallelFlag := flagSet.Bool("parallel", false, "run the dumps concurrently (only for the 'pg_dump' and 'direct' builders)")
 
 	snapshotCommands = append(snapshotCommands, &command{
 		flagSet: flagSet,
+		args:    pgdump.Builders,
 		handler: func(args []string) error {
 			if err := flagSet.Parse(args); err != nil {
 				return err
 			}
-			out := output.NewOutput(flagSet.Output(), output.OutputOpts{Verbose: *verbose})
-
 			var builder string
-			if len(args) > 0 {
-				builder = args[0]
+			if flagSet.NArg() > 0 {
+				// As shown in the usage, flags may also follow the builder.
+				builder = flagSet.Arg(0)
+				if err := flagSet.Parse(flagSet.Args()[1:]); err != nil {
+					return err
+				}
+				if flagSet.NArg() > 0 {
+					return cmderrors.Usage("additional arguments not allowed")
+				}
+			}
+			out := output.NewOutput(statusWriter(flagSet.Output()), output.OutputOpts{Verbose: *verbose})
+
+			buildOpts := pgdump.BuildOptions{
+				Compressed: *compressFlag,
+				Only:       splitDatabaseNames(*onlyFlag),
+				Skip:       splitDatabaseNames(*skipFlag),
+			}
+			databases, err := buildOpts.Databases()
+			if err != nil {
+				return cmderrors.Usage(err.Error())
+			}
+			mode, err := pgdump.ParseMode(*modeFlag)
+			if err != nil {
+				return cmderrors.Usage(err.Error())
+			}
+
+			// withOptions adds the mode and compression to the dump command itself,
+			// so that for remote builders compression happens inside the remote
+			// shell.
+			withOptions := func(cmd string) string {
+				cmd = pgdump.ModeCommand(cmd, mode)
+				if *compressFlag {
+					return pgdump.CompressCommand(cmd)
+				}
+				return cmd
 			}
 
 			targetKey := "docker"
-			var commandBuilder pgdump.CommandBuilder
+			parallel := false
 			switch builder {
 			case "pg_dump", "":
 				targetKey = "local"
-				commandBuilder = func(t pgdump.Target) (string, error) {
-					cmd := pgdump.Command(t)
-					if t.Target != "" {
-						return fmt.Sprintf("%s --host=%s", cmd, t.Target), nil
-					}
-					return cmd, nil
+				parallel = *parallelFlag
+			case "direct":
+				if *targetsKeyFlag == "auto" {
+					return cmderrors.Usage("the direct builder requires a targets file, e.g. --targets=targets.yaml")
 				}
+				parallel = *parallelFlag
 			case "docker":
-				commandBuilder = func(t pgdump.Target) (string, error) {
-					return fmt.Sprintf("docker exec -it %s sh -c '%s'", t.Target, pgdump.Command(t)), nil
-				}
 			case "kubectl":
 				targetKey = "k8s"
-				commandBuilder = func(t pgdump.Target) (string, error) {
-					return fmt.Sprintf("kubectl exec -it %s -- bash -c '%s'", t.Target, pgdump.Command(t)), nil
-				}
 			default:
 				return errors.Newf("unknown or invalid template type %q", builder)
 			}
+			commandBuilder, err := pgdump.Builder(builder, withOptions)
+			if err != nil {
+				return err
+			}
 			if *targetsKeyFlag != "auto" {
 				targetKey = *targetsKeyFlag
 			}
+			if *parallelFlag && !parallel {
+				out.WriteLine(output.Linef(output.EmojiWarning, output.StyleWarning, "Ignoring --parallel: the %s builder runs each dump in an exec session, which should not be run in the background", builder))
+			}
 
 			targets, ok := predefinedDatabaseDumpTargets[targetKey]
 			if !ok {
 				out.WriteLine(output.Emojif(output.EmojiInfo, "Using targets defined in targets file %q", targetKey))
-				f, err := os.Open(targetKey)
+				data, err := os.ReadFile(targetKey)
 				if err != nil {
 					return errors.Wrapf(err, "invalid targets file %q", targetKey)
 				}
-				if err := yaml.NewDecoder(f).Decode(&targets); err != nil {
+				if targets, err = pgdump.ParseTargets(data, databases); err != nil {
 					return errors.Wrapf(err, "invalid targets file %q", targetKey)
 				}
 			} else {
 				out.WriteLine(output.Emojif(output.EmojiInfo, "Using predefined targets for %s environments", targetKey))
 			}
 
-			commands, err := pgdump.BuildCommands(srcSnapshotDir, commandBuilder, targets)
+			commands, err := pgdump.BuildCommands(srcSnapshotDir, commandBuilder, targets, buildOpts)
 			if err != nil {
 				return errors.Wrap(err, "failed to build commands")
 			}
+			if parallel {
+				commands = pgdump.ParallelCommands(commands)
+			}
 
 			_ = os.MkdirAll(srcSnapshotDir, os.ModePerm)
 
@@ -106,6 +188,12 @@ TARGETS FILES
 			b.Close()
 
 			out.WriteLine(output.Styledf(output.StyleSuggestion, "Note that you may need to do some additional setup, such as authentication, beforehand."))
+			if parallel {
+				out.WriteLine(output.Styledf(output.StyleSuggestion, "The dumps run concurrently, which loads the database servers and the network with all of them at once; omit --parallel to run them one at a time. 'wait' does not report the exit status of the dumps, so check their output for errors."))
+			}
+			if mode == pgdump.ModeDataOnly {
+				out.WriteLine(output.Styledf(output.StyleSuggestion, "Data-only dumps restore into databases that already have the schema. Add --disable-triggers to the commands if foreign key constraints fail during the restore, and restore the dumps as a superuser."))
+			}
 
 			return nil
 		},
@@ -113,6 +201,30 @@ TARGETS FILES
 	})
 }
 
+// splitDatabaseNames splits a comma-separated list of database names.
+func splitDatabaseNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// snapshotDatabases returns the databases selected by the comma-separated lists of
+// the --only and --skip flags, as with 'src snapshot databases'.
+func snapshotDatabases(only, skip string) ([]string, error) {
+	databases, err := pgdump.BuildOptions{
+		Only: splitDatabaseNames(only),
+		Skip: splitDatabaseNames(skip),
+	}.Databases()
+	if err != nil {
+		return nil, cmderrors.Usage(err.Error())
+	}
+	return databases, nil
+}
+
 // predefinedDatabaseDumpTargets is based on default Sourcegraph configurations.
 var predefinedDatabaseDumpTargets = map[string]pgdump.Targets{
 	"local": {
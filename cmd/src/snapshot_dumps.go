@@ -0,0 +1,172 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"github.com/sourcegraph/sourcegraph/lib/output"
+
+	"github.com/sourcegraph/src-cli/internal/pgdump"
+)
+
+func init() {
+	usage := fmt.Sprintf(`'src snapshot dumps' reports on the contents of the snapshot directory %q, to sanity-check database dumps generated with the commands from 'src snapshot databases' before relying on them.
+
+Every file in the snapshot directory is listed with its size and modification time. Expected database dumps that are missing or empty are flagged, and the command exits with an error if there are any. If the dumps were generated with --only or --skip, pass the same flags to only expect the selected databases.
+
+USAGE
+	src [-v] snapshot dumps [-json] [--only=...] [--skip=...]
+`, srcSnapshotDir)
+	flagSet := flag.NewFlagSet("dumps", flag.ExitOnError)
+	jsonFlag := flagSet.Bool("json", false, "Print the report as JSON.")
+	onlyFlag := flagSet.String("only", "", "comma-separated list of the databases that were dumped ('primary', 'codeintel', 'codeinsights')")
+	skipFlag := flagSet.String("skip", "", "comma-separated list of the databases that were not dumped")
+
+	snapshotCommands = append(snapshotCommands, &command{
+		flagSet: flagSet,
+		handler: func(args []string) error {
+			if err := flagSet.Parse(args); err != nil {
+				return err
+			}
+			out := output.NewOutput(statusWriter(flagSet.Output()), output.OutputOpts{Verbose: *verbose})
+
+			databases, err := snapshotDatabases(*onlyFlag, *skipFlag)
+			if err != nil {
+				return err
+			}
+			files, err := summarizeSnapshotDumps(srcSnapshotDir, databases)
+			if err != nil {
+				return err
+			}
+
+			var problems int
+			for _, f := range files {
+				if f.Status != snapshotDumpOK {
+					problems++
+				}
+			}
+
+			if *jsonFlag {
+				data, err := marshalIndent(files)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(data))
+			} else {
+				t := table.NewWriter()
+				t.SetOutputMirror(os.Stdout)
+				t.AppendHeader(table.Row{"File", "Size", "Modified", "Status"})
+				for _, f := range files {
+					size, modified := "", ""
+					if f.Status != snapshotDumpMissing {
+						size = humanize.Bytes(uint64(f.Size))
+						modified = f.ModTime.Format(time.RFC3339)
+					}
+					t.AppendRow(table.Row{f.Path, size, modified, f.Status})
+				}
+				t.SetStyle(table.StyleRounded)
+				t.Render()
+			}
+
+			if problems > 0 {
+				out.WriteLine(output.Linef(output.EmojiFailure, output.StyleFailure,
+					"%d expected database dumps are missing or empty - regenerate them with the commands from 'src snapshot databases'", problems))
+				return errors.Newf("%d database dumps missing or empty", problems)
+			}
+			return nil
+		},
+		usageFunc: func() { fmt.Fprint(flag.CommandLine.Output(), usage) },
+	})
+}
+
+const (
+	snapshotDumpOK      = "ok"
+	snapshotDumpMissing = "missing"
+	snapshotDumpEmpty   = "empty"
+)
+
+// snapshotDumpFile describes a file in the snapshot directory.
+type snapshotDumpFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	// Expected is true if this file is a database dump generated by the
+	// commands from 'src snapshot databases'.
+	Expected bool   `json:"expected"`
+	Status   string `json:"status"`
+}
+
+// summarizeSnapshotDumps lists the files in dir, and reports any dumps of the
+// given databases that are missing or empty. The paths of the dumps don't
+// depend on the targets they were dumped from.
+func summarizeSnapshotDumps(dir string, databases []string) ([]snapshotDumpFile, error) {
+	selected := map[string]bool{}
+	for _, db := range databases {
+		selected[db] = true
+	}
+	expected := map[string]bool{}
+	for _, o := range pgdump.Outputs(dir, pgdump.Targets{}) {
+		if selected[o.Database] {
+			expected[filepath.Clean(o.Output)] = true
+		}
+	}
+
+	var files []snapshotDumpFile
+	seen := map[string]bool{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == dir {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		// Dumps generated with 'src snapshot databases --compress' are gzipped.
+		dump := strings.TrimSuffix(filepath.Clean(path), pgdump.CompressedExtension)
+		f := snapshotDumpFile{
+			Path:     path,
+			Size:     info.Size(),
+			ModTime:  info.ModTime(),
+			Expected: expected[dump],
+			Status:   snapshotDumpOK,
+		}
+		if f.Expected && f.Size == 0 {
+			f.Status = snapshotDumpEmpty
+		}
+		seen[dump] = true
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading snapshot directory %q", dir)
+	}
+
+	for path := range expected {
+		if !seen[path] {
+			files = append(files, snapshotDumpFile{
+				Path:     path,
+				Expected: true,
+				Status:   snapshotDumpMissing,
+			})
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
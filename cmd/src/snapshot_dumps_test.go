@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/src-cli/internal/pgdump"
+)
+
+func TestSummarizeSnapshotDumps(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"primary.sql":         "CREATE TABLE repo;",
+		"codeintel.sql":       "",
+		"codeinsights.sql.gz": "\x1f\x8b",
+		"summary.json":        "{}",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := summarizeSnapshotDumps(dir, pgdump.Databases)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		Path     string
+		Expected bool
+		Status   string
+	}
+	var got []result
+	for _, f := range files {
+		got = append(got, result{filepath.Base(f.Path), f.Expected, f.Status})
+	}
+	want := []result{
+		{"codeinsights.sql.gz", true, snapshotDumpOK},
+		{"codeintel.sql", true, snapshotDumpEmpty},
+		{"primary.sql", true, snapshotDumpOK},
+		{"summary.json", false, snapshotDumpOK},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected summary (-want +got):\n%s", diff)
+	}
+
+	t.Run("selected databases", func(t *testing.T) {
+		files, err := summarizeSnapshotDumps(dir, []string{"primary"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range files {
+			if f.Expected != (filepath.Base(f.Path) == "primary.sql") || f.Status != snapshotDumpOK {
+				t.Errorf("unexpected %q: expected %v, status %q", f.Path, f.Expected, f.Status)
+			}
+		}
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		files, err := summarizeSnapshotDumps(filepath.Join(dir, "does-not-exist"), pgdump.Databases)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 3 {
+			t.Fatalf("expected 3 missing dumps, got %+v", files)
+		}
+		for _, f := range files {
+			if f.Status != snapshotDumpMissing {
+				t.Errorf("expected %q to be missing, got %q", f.Path, f.Status)
+			}
+		}
+	})
+}
@@ -32,7 +32,7 @@ SUMMARY DATA
 			if err := flagSet.Parse(args); err != nil {
 				return err
 			}
-			out := output.NewOutput(flagSet.Output(), output.OutputOpts{Verbose: *verbose})
+			out := output.NewOutput(statusWriter(flagSet.Output()), output.OutputOpts{Verbose: *verbose})
 
 			client := cfg.apiClient(apiFlags, flagSet.Output())
 
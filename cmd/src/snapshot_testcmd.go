@@ -47,7 +47,7 @@ TEST DATA
 			if err := flagSet.Parse(args); err != nil {
 				return err
 			}
-			out := output.NewOutput(flagSet.Output(), output.OutputOpts{Verbose: *verbose})
+			out := output.NewOutput(statusWriter(flagSet.Output()), output.OutputOpts{Verbose: *verbose})
 			client := cfg.apiClient(apiFlags, flagSet.Output())
 
 			// Fetch health data
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"compress/gzip"
 	"context"
 	"flag"
 	"fmt"
@@ -28,7 +29,10 @@ func init() {
 	usage := `'src snapshot upload' uploads instance snapshot contents generated by 'src snapshot databases' and 'src snapshot summary' to the designated bucket.
 
 USAGE
-	src snapshot upload -bucket=$BUCKET -credentials=$CREDENTIALS_FILE
+	src snapshot upload -bucket=$BUCKET -credentials=$CREDENTIALS_FILE [--only=...] [--skip=...]
+
+DUMPS
+	Database dumps generated with 'src snapshot databases --compress' are uploaded compressed. If the dumps were generated with --only or --skip, pass the same flags to only upload the selected databases.
 
 BUCKET
 	In general, a Google Cloud Storage bucket and relevant credentials will be provided by Sourcegraph when using this functionality to share a snapshot with Sourcegraph.
@@ -37,6 +41,8 @@ BUCKET
 	bucketName := flagSet.String("bucket", "", "destination Cloud Storage bucket name")
 	credentialsPath := flagSet.String("credentials", "", "JSON credentials file for Google Cloud service account")
 	trimExtensions := flagSet.Bool("trim-extensions", true, "trim EXTENSION statements from database dumps for import to Google Cloud SQL")
+	onlyFlag := flagSet.String("only", "", "comma-separated list of the databases to upload dumps of ('primary', 'codeintel', 'codeinsights')")
+	skipFlag := flagSet.String("skip", "", "comma-separated list of the databases not to upload dumps of")
 
 	snapshotCommands = append(snapshotCommands, &command{
 		flagSet: flagSet,
@@ -51,8 +57,16 @@ BUCKET
 			if *credentialsPath == "" {
 				return errors.New("-credentials required")
 			}
+			databases, err := snapshotDatabases(*onlyFlag, *skipFlag)
+			if err != nil {
+				return err
+			}
+			selected := map[string]bool{}
+			for _, db := range databases {
+				selected[db] = true
+			}
 
-			out := output.NewOutput(flagSet.Output(), output.OutputOpts{Verbose: *verbose})
+			out := output.NewOutput(statusWriter(flagSet.Output()), output.OutputOpts{Verbose: *verbose})
 			ctx := context.Background()
 			c, err := storage.NewClient(ctx, option.WithCredentialsFile(*credentialsPath))
 			if err != nil {
@@ -63,6 +77,7 @@ BUCKET
 				file           *os.File
 				stat           os.FileInfo
 				trimExtensions bool
+				compressed     bool
 			}
 			var (
 				uploads      []upload             // index aligned with progressBars
@@ -90,7 +105,17 @@ BUCKET
 
 			// Open database dumps
 			for _, o := range pgdump.Outputs(srcSnapshotDir, pgdump.Targets{}) {
-				if f, err := os.Open(o.Output); err != nil {
+				if !selected[o.Database] {
+					continue
+				}
+				// Dumps generated with --compress are gzipped.
+				dumpPath, compressed := o.Output, false
+				if _, err := os.Stat(dumpPath); os.IsNotExist(err) {
+					if _, err := os.Stat(dumpPath + pgdump.CompressedExtension); err == nil {
+						dumpPath, compressed = dumpPath+pgdump.CompressedExtension, true
+					}
+				}
+				if f, err := os.Open(dumpPath); err != nil {
 					return errors.Wrap(err, "failed to database dump - generate one with 'src snapshot databases'")
 				} else {
 					stat, err := f.Stat()
@@ -101,6 +126,7 @@ BUCKET
 						file:           f,
 						stat:           stat,
 						trimExtensions: *trimExtensions,
+						compressed:     compressed,
 					})
 					progressBars = append(progressBars, output.ProgressBar{
 						Label: stat.Name(),
@@ -120,7 +146,11 @@ BUCKET
 				g.Go(func(ctx context.Context) error {
 					progressFn := func(p int64) { progress.SetValue(i, float64(p)) }
 
-					if err := copyDumpToBucket(ctx, u.file, u.stat, bucket, progressFn, u.trimExtensions); err != nil {
+					copyFn := copyDumpToBucket
+					if u.compressed && u.trimExtensions {
+						copyFn = copyCompressedDumpToBucket
+					}
+					if err := copyFn(ctx, u.file, u.stat, bucket, progressFn, u.trimExtensions); err != nil {
 						return errors.Wrap(err, u.stat.Name())
 					}
 
@@ -184,3 +214,30 @@ func copyDumpToBucket(ctx context.Context, src io.ReadSeeker, stat fs.FileInfo,
 
 	return nil
 }
+
+// copyCompressedDumpToBucket is copyDumpToBucket for dumps compressed with gzip, which
+// are decompressed to trim EXTENSION statements from them, and compressed again as
+// they are uploaded.
+func copyCompressedDumpToBucket(ctx context.Context, src io.ReadSeeker, stat fs.FileInfo, dst *storage.BucketHandle, progressFn func(int64), trimExtensions bool) error {
+	// Set up object to write to
+	object := dst.Object(stat.Name()).NewWriter(ctx)
+	object.ProgressFunc = progressFn
+	defer object.Close()
+
+	zr, err := gzip.NewReader(src)
+	if err != nil {
+		return errors.Wrap(err, "decompress")
+	}
+	zw := gzip.NewWriter(object)
+	if _, err := pgdump.CopyWithoutExtensions(zw, zr, func(int64) {}); err != nil {
+		return errors.Wrap(err, "trim extensions and upload")
+	}
+	if err := zw.Close(); err != nil {
+		return errors.Wrap(err, "upload")
+	}
+
+	// Progress is only reported as the object is written, so we call it manually
+	// after to update our pretty progress bars.
+	progressFn(stat.Size())
+	return nil
+}
@@ -22,6 +22,7 @@ The commands are:
 	delete     deletes a user account
 	clean      deletes inactive users
 	tag        add/remove a tag on a user
+	export     exports users to CSV
 
 Use "src users [command] -h" for more information about a command.
 `
@@ -34,9 +35,10 @@ Use "src users [command] -h" for more information about a command.
 
 	// Register the command.
 	commands = append(commands, &command{
-		flagSet: flagSet,
-		aliases: []string{"user"},
-		handler: handler,
+		flagSet:     flagSet,
+		aliases:     []string{"user"},
+		handler:     handler,
+		subcommands: &usersCommands,
 		usageFunc: func() {
 			fmt.Println(usage)
 		},
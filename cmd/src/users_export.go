@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/src-cli/internal/api"
+	"github.com/sourcegraph/src-cli/internal/cmderrors"
+)
+
+func init() {
+	usage := `
+Examples:
+
+  Export the username, email, and last active time of all users to a CSV file:
+
+    	$ src users export -o users.csv
+
+  Choose the columns to export:
+
+    	$ src users export -fields=username,displayName,emails,siteAdmin
+
+  Export the users with the "foo" tag to standard output:
+
+    	$ src users export -tag=foo
+
+Available fields: ` + strings.Join(usersExportFieldNames, ", ") + `
+
+The email field is the user's first verified email address, or their first
+email address if none is verified. The emails field lists all of the user's
+email addresses, separated by semicolons.
+`
+
+	flagSet := flag.NewFlagSet("export", flag.ExitOnError)
+	usageFunc := func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of 'src users %s':\n", flagSet.Name())
+		flagSet.PrintDefaults()
+		fmt.Println(usage)
+	}
+	var (
+		outputFlag = flagSet.String("o", "", "The file to write the CSV to. Default is standard output.")
+		fieldsFlag = flagSet.String("fields", "username,email,lastActive", "Comma-separated list of the fields to export, in column order.")
+		queryFlag  = flagSet.String("query", "", `Export users whose names match the query. (e.g. "alice")`)
+		tagFlag    = flagSet.String("tag", "", `Export users with the given tag.`)
+		apiFlags   = api.NewFlags(flagSet)
+	)
+
+	handler := func(args []string) error {
+		if err := flagSet.Parse(args); err != nil {
+			return err
+		}
+		if flagSet.NArg() != 0 {
+			return cmderrors.Usage("additional arguments not allowed")
+		}
+		fields, err := parseUsersExportFields(*fieldsFlag)
+		if err != nil {
+			return cmderrors.Usage(err.Error())
+		}
+
+		ctx := context.Background()
+		client := cfg.apiClient(apiFlags, flagSet.Output())
+
+		users, err := listAllUsers(ctx, client, *queryFlag, *tagFlag)
+		if err != nil || users == nil {
+			return err
+		}
+
+		if *outputFlag == "" {
+			return writeUsersCSV(os.Stdout, users, fields)
+		}
+		f, err := os.Create(*outputFlag)
+		if err != nil {
+			return err
+		}
+		if err := writeUsersCSV(f, users, fields); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Fprintf(flagSet.Output(), "%d users exported to %s\n", len(users), *outputFlag)
+		return nil
+	}
+
+	// Register the command.
+	usersCommands = append(usersCommands, &command{
+		flagSet:   flagSet,
+		handler:   handler,
+		usageFunc: usageFunc,
+	})
+}
+
+// usersExportFields maps the fields that can be exported with 'src users export'
+// to their values.
+var usersExportFields = map[string]func(User) string{
+	"id":          func(u User) string { return u.ID },
+	"username":    func(u User) string { return u.Username },
+	"displayName": func(u User) string { return u.DisplayName },
+	"email": func(u User) string {
+		for _, e := range u.Emails {
+			if e.Verified {
+				return e.Email
+			}
+		}
+		if len(u.Emails) > 0 {
+			return u.Emails[0].Email
+		}
+		return ""
+	},
+	"emails": func(u User) string {
+		emails := make([]string, 0, len(u.Emails))
+		for _, e := range u.Emails {
+			emails = append(emails, e.Email)
+		}
+		return strings.Join(emails, ";")
+	},
+	"siteAdmin":  func(u User) string { return strconv.FormatBool(u.SiteAdmin) },
+	"lastActive": func(u User) string { return u.UsageStatistics.LastActiveTime },
+	"organizations": func(u User) string {
+		orgs := make([]string, 0, len(u.Organizations.Nodes))
+		for _, org := range u.Organizations.Nodes {
+			orgs = append(orgs, org.Name)
+		}
+		return strings.Join(orgs, ";")
+	},
+	"url": func(u User) string { return u.URL },
+}
+
+// usersExportFieldNames are the keys of usersExportFields, in the order they are
+// documented.
+var usersExportFieldNames = []string{"id", "username", "displayName", "email", "emails", "siteAdmin", "lastActive", "organizations", "url"}
+
+// parseUsersExportFields parses a comma-separated list of field names.
+func parseUsersExportFields(list string) ([]string, error) {
+	var fields []string
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if _, ok := usersExportFields[field]; !ok {
+			return nil, errors.Newf("unknown field %q, must be one of %s", field, strings.Join(usersExportFieldNames, ", "))
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("no fields given")
+	}
+	return fields, nil
+}
+
+// writeUsersCSV writes a header row with the field names, followed by a row per
+// user.
+func writeUsersCSV(w io.Writer, users []User, fields []string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(fields); err != nil {
+		return err
+	}
+	row := make([]string, len(fields))
+	for _, u := range users {
+		for i, field := range fields {
+			row[i] = usersExportFields[field](u)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+const listAllUsersQuery = `query Users(
+  $first: Int,
+  $after: String,
+  $query: String,
+  $tag: String,
+) {
+  users(
+    first: $first,
+    after: $after,
+    query: $query,
+    tag: $tag,
+  ) {
+    nodes {
+      ...UserFields
+    }
+    pageInfo {
+      hasNextPage
+      endCursor
+    }
+  }
+}
+` + userFragment
+
+// listAllUsers follows the users connection cursor until all users matching the
+// query and tag have been fetched.
+//
+// A nil slice and nil error are returned if no data was available, for example
+// because -get-curl was set.
+func listAllUsers(ctx context.Context, client api.Client, query, tag string) ([]User, error) {
+	users := []User{}
+	var after *string
+	for {
+		var result struct {
+			Users struct {
+				Nodes    []User
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   *string
+				}
+			}
+		}
+		if ok, err := client.NewRequest(listAllUsersQuery, map[string]interface{}{
+			"first": 100,
+			"after": after,
+			"query": api.NullString(query),
+			"tag":   api.NullString(tag),
+		}).Do(ctx, &result); err != nil || !ok {
+			return nil, err
+		}
+
+		users = append(users, result.Users.Nodes...)
+
+		pageInfo := result.Users.PageInfo
+		if !pageInfo.HasNextPage || pageInfo.EndCursor == nil {
+			return users, nil
+		}
+		after = pageInfo.EndCursor
+	}
+}
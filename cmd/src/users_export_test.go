@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteUsersCSV(t *testing.T) {
+	users := []User{
+		{
+			Username:    "alice",
+			DisplayName: "Alice, Jr.",
+			Emails: []UserEmail{
+				{Email: "alice@old.example.com"},
+				{Email: "alice@example.com", Verified: true},
+			},
+			UsageStatistics: UserUsageStatistics{LastActiveTime: "2022-01-02T03:04:05Z"},
+		},
+		{
+			Username:    "bob",
+			DisplayName: `Bob "the builder"`,
+			Emails:      []UserEmail{{Email: "bob@example.com"}},
+		},
+		{Username: "carol"},
+	}
+
+	fields, err := parseUsersExportFields("username, displayName,email,emails,lastActive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeUsersCSV(&buf, users, fields); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `username,displayName,email,emails,lastActive
+alice,"Alice, Jr.",alice@example.com,alice@old.example.com;alice@example.com,2022-01-02T03:04:05Z
+bob,"Bob ""the builder""",bob@example.com,bob@example.com,
+carol,,,,
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("unexpected CSV (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseUsersExportFields(t *testing.T) {
+	for _, list := range []string{"", ",", "username,password"} {
+		if _, err := parseUsersExportFields(list); err == nil {
+			t.Errorf("expected error for %q", list)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 
 	"github.com/sourcegraph/src-cli/internal/api"
 	"github.com/sourcegraph/src-cli/internal/version"
@@ -19,6 +20,10 @@ Examples:
   Get the src-cli version and the Sourcegraph instance's recommended version:
 
     	$ src version
+
+  Get the versions as JSON:
+
+    	$ src -output-format=json version
 `
 
 	flagSet := flag.NewFlagSet("version", flag.ExitOnError)
@@ -29,6 +34,10 @@ Examples:
 	)
 
 	handler := func(args []string) error {
+		if jsonOutput() {
+			return printVersionJSON(*clientOnly, apiFlags, flagSet.Output())
+		}
+
 		fmt.Printf("Current version: %s\n", version.BuildTag)
 		if clientOnly != nil && *clientOnly {
 			return nil
@@ -50,8 +59,9 @@ Examples:
 
 	// Register the command.
 	commands = append(commands, &command{
-		flagSet: flagSet,
-		handler: handler,
+		flagSet:      flagSet,
+		handler:      handler,
+		outputFormat: true,
 		usageFunc: func() {
 			fmt.Fprintf(flag.CommandLine.Output(), "Usage of 'src %s':\n", flagSet.Name())
 			flagSet.PrintDefaults()
@@ -60,6 +70,28 @@ Examples:
 	})
 }
 
+// versionOutput is the data printed by 'src version' with -output-format=json.
+type versionOutput struct {
+	Current string `json:"current"`
+	// Recommended is the version recommended by the Sourcegraph instance. It
+	// is omitted with -client-only, and empty if the instance does not
+	// support recommending a version.
+	Recommended *string `json:"recommended,omitempty"`
+}
+
+func printVersionJSON(clientOnly bool, apiFlags *api.Flags, out io.Writer) error {
+	data := versionOutput{Current: version.BuildTag}
+	if !clientOnly {
+		client := cfg.apiClient(apiFlags, out)
+		recommendedVersion, err := getRecommendedVersion(context.Background(), client)
+		if err != nil {
+			return err
+		}
+		data.Recommended = &recommendedVersion
+	}
+	return writeOutputEnvelope(os.Stdout, data)
+}
+
 func getRecommendedVersion(ctx context.Context, client api.Client) (string, error) {
 	req, err := client.NewHTTPRequest(ctx, "GET", ".api/src-cli/version", nil)
 	if err != nil {
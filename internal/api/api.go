@@ -4,14 +4,15 @@ package api
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	ioaux "github.com/jig/teereadcloser"
 	"github.com/kballard/go-shellquote"
@@ -81,6 +82,19 @@ type ClientOpts struct {
 	// Out is the writer that will be used when outputting diagnostics, such as
 	// curl commands when -get-curl is enabled.
 	Out io.Writer
+
+	// Verbose enables printing the trace ID of every request to Out, as the
+	// -trace flag does.
+	Verbose bool
+
+	// Logger, if set, receives a line for every request made by the client,
+	// including its status, duration and trace IDs.
+	Logger *log.Logger
+
+	// VersionCheck enables warning on Out if the version of the instance is
+	// incompatible with that of src. The version is fetched before the first
+	// GraphQL request of the process.
+	VersionCheck bool
 }
 
 // NewClient creates a new API client.
@@ -95,10 +109,13 @@ func NewClient(opts ClientOpts) Client {
 	}
 
 	httpClient := http.DefaultClient
-	if flags.insecureSkipVerify != nil && *flags.insecureSkipVerify {
-		httpClient = &http.Client{
-			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
-		}
+	if tlsConfig, err := flags.tlsConfig(); err != nil {
+		// NewClient can't fail, so report invalid TLS flags on the first request.
+		httpClient = &http.Client{Transport: errorTransport{err}}
+	} else if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		httpClient = &http.Client{Transport: transport}
 	}
 
 	return &client{
@@ -108,11 +125,21 @@ func NewClient(opts ClientOpts) Client {
 			AdditionalHeaders: opts.AdditionalHeaders,
 			Flags:             flags,
 			Out:               opts.Out,
+			Verbose:           opts.Verbose,
+			Logger:            opts.Logger,
+			VersionCheck:      opts.VersionCheck,
 		},
 		httpClient: httpClient,
 	}
 }
 
+// errorTransport is an http.RoundTripper that fails every request with err.
+type errorTransport struct{ err error }
+
+func (t errorTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
 func (c *client) NewQuery(query string) Request {
 	return c.NewRequest(query, nil)
 }
@@ -126,7 +153,24 @@ func (c *client) NewRequest(query string, vars map[string]interface{}) Request {
 }
 
 func (c *client) Do(req *http.Request) (*http.Response, error) {
-	return c.httpClient.Do(req)
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	c.logRequest(req, resp, err, time.Since(start))
+	return resp, err
+}
+
+// logRequest writes a line describing the request and its response to the
+// client's logger, if any.
+func (c *client) logRequest(req *http.Request, resp *http.Response, err error, d time.Duration) {
+	if c.opts.Logger == nil {
+		return
+	}
+	if err != nil {
+		c.opts.Logger.Printf("request method=%s url=%s duration=%s error=%q", req.Method, req.URL, d, err)
+		return
+	}
+	c.opts.Logger.Printf("request method=%s url=%s duration=%s status=%d x-trace=%q x-request-id=%q",
+		req.Method, req.URL, d, resp.StatusCode, resp.Header.Get("X-Trace"), resp.Header.Get("X-Request-Id"))
 }
 
 func (c *client) NewHTTPRequest(ctx context.Context, method, p string, body io.Reader) (*http.Request, error) {
@@ -157,18 +201,28 @@ func (c *client) createHTTPRequest(ctx context.Context, method, p string, body i
 	for k, v := range c.opts.AdditionalHeaders {
 		req.Header.Set(k, v)
 	}
+	header, err := c.opts.Flags.header()
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
 
 	return req, nil
 }
 
-func (r *request) do(ctx context.Context, result interface{}) (bool, error) {
+// do performs the request and decodes the response into result. The returned
+// trace ID identifies the request in the Sourcegraph server logs, and is empty
+// if the server did not return one.
+func (r *request) do(ctx context.Context, result interface{}) (ok bool, traceID string, err error) {
 	if *r.client.opts.Flags.getCurl {
 		curl, err := r.curlCmd()
 		if err != nil {
-			return false, err
+			return false, traceID, err
 		}
 		_, err = r.client.opts.Out.Write([]byte(curl + "\n"))
-		return false, err
+		return false, "", err
 	}
 
 	if *r.client.opts.Flags.dump {
@@ -178,7 +232,7 @@ func (r *request) do(ctx context.Context, result interface{}) (bool, error) {
 			for k, v := range r.vars {
 				value, err := json.Marshal(v)
 				if err != nil {
-					return false, err
+					return false, traceID, err
 				}
 				fmt.Fprintf(r.client.opts.Out, "    %s: %s\n", k, string(value))
 			}
@@ -192,7 +246,7 @@ func (r *request) do(ctx context.Context, result interface{}) (bool, error) {
 		"variables": r.vars,
 	})
 	if err != nil {
-		return false, err
+		return false, traceID, err
 	}
 
 	var bufBody io.Reader = bytes.NewBuffer(reqBody)
@@ -201,24 +255,25 @@ func (r *request) do(ctx context.Context, result interface{}) (bool, error) {
 	// Create the HTTP request.
 	req, err := r.client.NewHTTPRequest(ctx, "POST", ".api/graphql", bufBody)
 	if err != nil {
-		return false, err
+		return false, traceID, err
 	}
 
 	// Use gzip compression.
 	req.Header.Set("Content-Encoding", "gzip")
 
 	// Perform the request.
-	resp, err := r.client.httpClient.Do(req)
+	resp, err := r.client.Do(req)
 	if err != nil {
-		return false, err
+		return false, traceID, err
 	}
 	defer resp.Body.Close()
+	traceID = responseTraceID(resp)
 
 	// Check trace header before we potentially early exit
-	if *r.client.opts.Flags.trace {
-		_, err := r.client.opts.Out.Write([]byte(fmt.Sprintf("x-trace: %s\n", resp.Header.Get("x-trace"))))
+	if *r.client.opts.Flags.trace || r.client.opts.Verbose {
+		_, err := r.client.opts.Out.Write([]byte(fmt.Sprintf("x-trace: %s\n", traceID)))
 		if err != nil {
-			return false, err
+			return false, traceID, err
 		}
 	}
 
@@ -233,9 +288,14 @@ func (r *request) do(ctx context.Context, result interface{}) (bool, error) {
 		}
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return false, err
+			return false, traceID, err
+		}
+		return false, traceID, &HTTPError{
+			Status:     resp.Status,
+			StatusCode: resp.StatusCode,
+			Body:       body,
+			TraceID:    traceID,
 		}
-		return false, fmt.Errorf("error: %s\n\n%s", resp.Status, body)
 	}
 
 	body := resp.Body
@@ -251,19 +311,27 @@ func (r *request) do(ctx context.Context, result interface{}) (bool, error) {
 
 	// Decode the response.
 	if err := json.NewDecoder(body).Decode(result); err != nil {
-		return false, err
+		return false, traceID, err
 	}
 
-	return true, nil
+	return true, traceID, nil
 }
 
 // Do executes the request. Successful requests will be unmarshalled into the
 // given result. If GraphQL errors are returned, then the returned error will be
-// an instance of GraphQlErrors. Other errors (such as HTTP or network errors)
-// will be returned as-is.
+// an instance of GraphQlErrors, even if result is nil or the response also
+// contains data. Other errors (such as HTTP or network errors) will be returned
+// as-is.
 func (r *request) Do(ctx context.Context, result interface{}) (bool, error) {
+	r.client.checkVersion(ctx)
+
 	raw := rawResult{Data: result}
-	ok, err := r.do(ctx, &raw)
+	if result == nil {
+		// The caller only cares about errors, so don't decode the data into a
+		// generic value.
+		raw.Data = &json.RawMessage{}
+	}
+	ok, traceID, err := r.do(ctx, &raw)
 	if err != nil {
 		return false, err
 	} else if !ok {
@@ -274,7 +342,7 @@ func (r *request) Do(ctx context.Context, result interface{}) (bool, error) {
 	if raw.Errors != nil {
 		errs := GraphQlErrors{}
 		for _, err := range raw.Errors {
-			errs = append(errs, &GraphQlError{err})
+			errs = append(errs, &GraphQlError{v: err, traceID: traceID})
 		}
 		return false, errs
 	}
@@ -282,7 +350,18 @@ func (r *request) Do(ctx context.Context, result interface{}) (bool, error) {
 }
 
 func (r *request) DoRaw(ctx context.Context, result interface{}) (bool, error) {
-	return r.do(ctx, result)
+	r.client.checkVersion(ctx)
+	ok, _, err := r.do(ctx, result)
+	return ok, err
+}
+
+// responseTraceID returns the ID that correlates the response with the
+// Sourcegraph server logs, preferring the trace ID over the request ID.
+func responseTraceID(resp *http.Response) string {
+	if id := resp.Header.Get("X-Trace"); id != "" {
+		return id
+	}
+	return resp.Header.Get("X-Request-Id")
 }
 
 type rawResult struct {
@@ -306,6 +385,15 @@ func (r *request) curlCmd() (string, error) {
 	for k, v := range r.client.opts.AdditionalHeaders {
 		s += fmt.Sprintf("   %s \\\n", shellquote.Join("-H", k+": "+v))
 	}
+	header, err := r.client.opts.Flags.header()
+	if err != nil {
+		return "", err
+	}
+	for k, values := range header {
+		for _, v := range values {
+			s += fmt.Sprintf("   %s \\\n", shellquote.Join("-H", k+": "+v))
+		}
+	}
 	s += fmt.Sprintf("   %s \\\n", shellquote.Join("-d", string(data)))
 	s += fmt.Sprintf("   %s", shellquote.Join(r.client.opts.Endpoint+"/.api/graphql"))
 	return s, nil
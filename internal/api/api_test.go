@@ -1,3 +1,335 @@
 package api
 
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/src-cli/internal/version"
+)
+
 // TODO: implement a super basic GraphQL server that can return canned results.
+
+func TestClientLogger(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Trace", "abc123")
+		w.Write([]byte(`{"data": {}}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	var buf bytes.Buffer
+	client := NewClient(ClientOpts{
+		Endpoint: ts.URL,
+		Out:      io.Discard,
+		Logger:   log.New(&buf, "", 0),
+	})
+
+	var result struct{}
+	if ok, err := client.NewQuery(`query { currentUser { id } }`).Do(context.Background(), &result); err != nil || !ok {
+		t.Fatalf("unexpected result: ok=%v err=%v", ok, err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{"method=POST", "url=" + ts.URL + "/.api/graphql", "status=200", `x-trace="abc123"`, "duration="} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log %q does not contain %q", got, want)
+		}
+	}
+}
+
+func TestRequestGraphQLErrors(t *testing.T) {
+	for name, body := range map[string]string{
+		"no data":      `{"data": null, "errors": [{"message": "boom", "path": ["addRepoKeyValuePair"]}]}`,
+		"partial data": `{"data": {"addRepoKeyValuePair": {"alwaysNil": null}}, "errors": [{"message": "boom"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(body))
+			}))
+			t.Cleanup(ts.Close)
+
+			client := NewClient(ClientOpts{Endpoint: ts.URL, Out: io.Discard})
+
+			var result struct {
+				AddRepoKeyValuePair *struct{ AlwaysNil *string }
+			}
+			for resultName, result := range map[string]interface{}{"nil result": nil, "struct result": &result} {
+				ok, err := client.NewQuery(`mutation { addRepoKeyValuePair { alwaysNil } }`).Do(context.Background(), result)
+				if ok {
+					t.Errorf("%s: unexpected ok", resultName)
+				}
+				errs, isGraphQL := err.(GraphQlErrors)
+				if !isGraphQL || len(errs) != 1 {
+					t.Fatalf("%s: unexpected error %v", resultName, err)
+				}
+				if !strings.Contains(errs[0].Error(), "boom") {
+					t.Errorf("%s: unexpected error %q", resultName, errs[0])
+				}
+			}
+		})
+	}
+}
+
+func TestRequestErrorTraceID(t *testing.T) {
+	for name, tc := range map[string]struct {
+		header string
+		status int
+		body   string
+	}{
+		"empty 5xx response": {
+			header: "X-Request-Id",
+			status: http.StatusBadGateway,
+		},
+		"graphql errors": {
+			header: "X-Trace",
+			status: http.StatusOK,
+			body:   `{"errors": [{"message": "boom"}]}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(tc.header, "abc123")
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			t.Cleanup(ts.Close)
+
+			client := NewClient(ClientOpts{Endpoint: ts.URL, Out: io.Discard})
+
+			var result struct{}
+			_, err := client.NewQuery(`query { currentUser { id } }`).Do(context.Background(), &result)
+			if err == nil {
+				t.Fatal("unexpected nil error")
+			}
+			if want := "trace ID: abc123"; !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not contain %q", err, want)
+			}
+		})
+	}
+}
+
+func TestClientHeaders(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		w.Write([]byte(`{"data": {}}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	query := func(args ...string) error {
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags := NewFlags(flagSet)
+		// Parse the flags twice, as commander.run and then the command's
+		// handler do.
+		for i := 0; i < 2; i++ {
+			if err := flagSet.Parse(args); err != nil {
+				t.Fatal(err)
+			}
+		}
+		client := NewClient(ClientOpts{Endpoint: ts.URL, AccessToken: "secret", Out: io.Discard, Flags: flags})
+		var result struct{}
+		_, err := client.NewQuery(`query { currentUser { id } }`).Do(context.Background(), &result)
+		return err
+	}
+
+	if err := query("-header", "X-Team-Id: 42", "-header=x-route:  blue "); err != nil {
+		t.Fatal(err)
+	}
+	if got.Get("X-Team-Id") != "42" || got.Get("X-Route") != "blue" {
+		t.Errorf("unexpected headers: %v", got)
+	}
+	if values := got.Values("X-Team-Id"); len(values) != 1 {
+		t.Errorf("X-Team-Id sent %d times: %v", len(values), values)
+	}
+
+	if err := query("-header=Authorization: token other"); err == nil || !strings.Contains(err.Error(), "reserved") {
+		t.Errorf("unexpected error overriding Authorization: %v", err)
+	}
+	if err := query("-allow-reserved-headers", "-header=Authorization: token other"); err != nil {
+		t.Fatal(err)
+	}
+	if got.Get("Authorization") != "token other" {
+		t.Errorf("unexpected Authorization header %q", got.Get("Authorization"))
+	}
+
+	for _, h := range []string{"X-Team-Id", ": value", "X Team: 42"} {
+		if err := query("-header=" + h); err == nil || !strings.Contains(err.Error(), "invalid -header") {
+			t.Errorf("-header=%q: unexpected error %v", h, err)
+		}
+	}
+}
+
+func TestClientTLS(t *testing.T) {
+	dir := t.TempDir()
+	clientCert, clientKey := writeTestCertificate(t, dir)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {}}`))
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+
+	caCert := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	query := func(args ...string) error {
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags := NewFlags(flagSet)
+		if err := flagSet.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		client := NewClient(ClientOpts{Endpoint: ts.URL, Out: io.Discard, Flags: flags})
+		var result struct{}
+		_, err := client.NewQuery(`query { currentUser { id } }`).Do(context.Background(), &result)
+		return err
+	}
+
+	if err := query("-cacert="+caCert, "-client-cert="+clientCert, "-client-key="+clientKey); err != nil {
+		t.Errorf("unexpected error with client certificate: %s", err)
+	}
+	if err := query("-cacert=" + caCert); err == nil {
+		t.Error("unexpected nil error without client certificate")
+	}
+	if err := query("-client-cert=" + clientCert); err == nil || !strings.Contains(err.Error(), "must be given together") {
+		t.Errorf("unexpected error with only -client-cert: %v", err)
+	}
+}
+
+// writeTestCertificate writes a self-signed certificate and its key to dir, and
+// returns their paths.
+func writeTestCertificate(t *testing.T, dir string) (certPath, keyPath string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "src-cli test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath = filepath.Join(dir, "client.pem")
+	keyPath = filepath.Join(dir, "client-key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+func TestClientVersionCheck(t *testing.T) {
+	buildTag := version.BuildTag
+	version.BuildTag = "5.0.1"
+	t.Cleanup(func() {
+		version.BuildTag = buildTag
+		versionCheckOnce = sync.Once{}
+	})
+
+	var versionQueries int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, err := io.ReadAll(zr)
+		if err != nil {
+			t.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if bytes.Contains(body, []byte("productVersion")) {
+			atomic.AddInt32(&versionQueries, 1)
+			w.Write([]byte(`{"data": {"site": {"productVersion": "5.3.0"}}}`))
+			return
+		}
+		w.Write([]byte(`{"data": {}}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	var out bytes.Buffer
+	for _, opts := range []ClientOpts{
+		{Endpoint: ts.URL, Out: &out, VersionCheck: true},
+		{Endpoint: ts.URL, Out: &out, VersionCheck: true},
+	} {
+		client := NewClient(opts)
+		for i := 0; i < 2; i++ {
+			if _, err := client.NewQuery(`query { currentUser { id } }`).Do(context.Background(), nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if n := atomic.LoadInt32(&versionQueries); n != 1 {
+		t.Errorf("version queried %d times, want 1", n)
+	}
+	if got := out.String(); strings.Count(got, "Warning") != 1 || !strings.Contains(got, "5.3.0") {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
+
+func TestVersionsIncompatible(t *testing.T) {
+	for _, tc := range []struct {
+		src, instance string
+		want          bool
+	}{
+		{"5.2.3", "5.2.0", false},
+		{"5.2.3", "5.3.1", false},
+		{"5.3.1", "5.2.3", false},
+		{"5.0.0", "5.2.3", true},
+		{"5.4.0", "5.2.3", true},
+		{"4.5.1", "5.0.0", false},
+		{"5.0.0", "4.5.1", false},
+		{"4.4.2", "5.0.0", true},
+		{"4.5.1", "5.1.0", true},
+		{"3.43.2", "4.0.1", false},
+		{"3.43.2", "5.0.0", true},
+		{"9.3.0", "10.0.0", false},
+		{"9.3.0", "10.1.0", true},
+		{"dev", "5.2.3", false},
+		{"5.2.3", "0.0.0+dev", false},
+		{"5.2.3", "235883_2023-10-12_5.2-abcdef123456", false},
+	} {
+		if have := versionsIncompatible(tc.src, tc.instance); have != tc.want {
+			t.Errorf("versionsIncompatible(%q, %q): have %v, want %v", tc.src, tc.instance, have, tc.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
@@ -24,11 +25,25 @@ func (gg GraphQlErrors) Error() string {
 		errs = errors.Append(errs, err)
 	}
 
-	return errors.Wrap(errs, "GraphQL errors").Error()
+	msg := errors.Wrap(errs, "GraphQL errors").Error()
+	if traceID := gg[0].TraceID(); traceID != "" {
+		msg += fmt.Sprintf("\n\ntrace ID: %s", traceID)
+	}
+	return msg
 }
 
 // GraphQlError wraps a raw JSON error returned from a GraphQL endpoint.
-type GraphQlError struct{ v interface{} }
+type GraphQlError struct {
+	v       interface{}
+	traceID string
+}
+
+// TraceID returns the ID of the request that returned the error, as reported
+// in the X-Trace or X-Request-ID response header. It can be given to
+// Sourcegraph support to find the request in the server logs.
+func (g *GraphQlError) TraceID() string {
+	return g.traceID
+}
 
 // Code returns the GraphQL error code, if one was set on the error.
 func (g *GraphQlError) Code() (string, error) {
@@ -69,7 +84,27 @@ func (g *GraphQlError) Extensions() (map[string]interface{}, error) {
 	return nil, errors.Errorf("unexpected extensions of type %T", e["extensions"])
 }
 
+// HTTPError is returned when the Sourcegraph API responds with a status other
+// than 200 OK.
+type HTTPError struct {
+	Status     string
+	StatusCode int
+	Body       []byte
+	// TraceID is the ID of the request as reported in the X-Trace or
+	// X-Request-ID response header, if any.
+	TraceID string
+}
+
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("error: %s\n\n%s", e.Status, e.Body)
+	if e.TraceID != "" {
+		msg += fmt.Sprintf("\n\ntrace ID: %s", e.TraceID)
+	}
+	return msg
+}
+
 var (
+	_ error = &HTTPError{}
 	_ error = &GraphQlError{}
 	_ error = GraphQlErrors{}
 )
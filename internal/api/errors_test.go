@@ -99,7 +99,7 @@ func TestGraphQLError_Code(t *testing.T) {
 				t.Fatalf("unexpected number of GraphQL errors (this test can only handle one!): %d", ne)
 			}
 
-			ge := &GraphQlError{result.Errors[0]}
+			ge := &GraphQlError{v: result.Errors[0]}
 			have, err := ge.Code()
 			if tc.wantErr {
 				if err == nil {
@@ -1,8 +1,14 @@
 package api
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"flag"
+	"net/http"
 	"os"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
 // Flags encapsulates the standard flags that should be added to all commands
@@ -13,6 +19,11 @@ type Flags struct {
 	trace              *bool
 	insecureSkipVerify *bool
 	userAgentTelemetry *bool
+	clientCert         *string
+	clientKey          *string
+	caCert             *string
+	headers            *headerFlag
+	allowReserved      *bool
 }
 
 func (f *Flags) Trace() bool {
@@ -29,27 +40,132 @@ func (f *Flags) UserAgentTelemetry() bool {
 	return *(f.userAgentTelemetry)
 }
 
+// tlsConfig returns the TLS configuration for the flags, or nil if the default
+// configuration should be used.
+func (f *Flags) tlsConfig() (*tls.Config, error) {
+	insecure := f.insecureSkipVerify != nil && *f.insecureSkipVerify
+	clientCert := f.clientCert != nil && *f.clientCert != ""
+	clientKey := f.clientKey != nil && *f.clientKey != ""
+	caCert := f.caCert != nil && *f.caCert != ""
+	if !insecure && !clientCert && !clientKey && !caCert {
+		return nil, nil
+	}
+
+	config := &tls.Config{InsecureSkipVerify: insecure}
+
+	if clientCert != clientKey {
+		return nil, errors.New("-client-cert and -client-key must be given together")
+	}
+	if clientCert {
+		cert, err := tls.LoadX509KeyPair(*f.clientCert, *f.clientKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading client certificate")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if caCert {
+		pem, err := os.ReadFile(*f.caCert)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading CA certificate")
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Newf("no PEM encoded certificates found in %q", *f.caCert)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+// headerFlag is a repeatable flag of "Name: Value" HTTP headers.
+type headerFlag []string
+
+func (h *headerFlag) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlag) Set(v string) error {
+	*h = append(*h, v)
+	return nil
+}
+
+// reservedHeaders are set by the client itself, and can only be overridden with
+// -header if -allow-reserved-headers is given.
+var reservedHeaders = []string{"Authorization", "Content-Encoding", "Content-Length", "Content-Type", "Host", "User-Agent"}
+
+// header returns the HTTP headers given with -header. Repeated headers are only
+// added once, since commands parse their flag set both in commander.run and in
+// their handler, which appends each -header to the flag twice.
+func (f *Flags) header() (http.Header, error) {
+	header := http.Header{}
+	if f.headers == nil {
+		return header, nil
+	}
+	allowReserved := f.allowReserved != nil && *f.allowReserved
+	seen := map[string]bool{}
+	for _, h := range *f.headers {
+		if seen[h] {
+			continue
+		}
+		seen[h] = true
+
+		name, value, ok := strings.Cut(h, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, errors.Newf("invalid -header %q: expected \"Name: Value\"", h)
+		}
+		name = http.CanonicalHeaderKey(name)
+		if !allowReserved {
+			for _, reserved := range reservedHeaders {
+				if name == reserved {
+					return nil, errors.Newf("-header cannot set the reserved %s header without -allow-reserved-headers", name)
+				}
+			}
+		}
+		header.Add(name, strings.TrimSpace(value))
+	}
+	return header, nil
+}
+
 // NewFlags instantiates a new Flags structure and attaches flags to the given
 // flag set.
 func NewFlags(flagSet *flag.FlagSet) *Flags {
+	headers := &headerFlag{}
+	flagSet.Var(headers, "header", `Add an HTTP header to requests, as "Name: Value". Can be repeated`)
 	return &Flags{
 		dump:               flagSet.Bool("dump-requests", false, "Log GraphQL requests and responses to stdout"),
 		getCurl:            flagSet.Bool("get-curl", false, "Print the curl command for executing this query and exit (WARNING: includes printing your access token!)"),
 		trace:              flagSet.Bool("trace", false, "Log the trace ID for requests. See https://docs.sourcegraph.com/admin/observability/tracing"),
 		insecureSkipVerify: flagSet.Bool("insecure-skip-verify", false, "Skip validation of TLS certificates against trusted chains"),
 		userAgentTelemetry: flagSet.Bool("user-agent-telemetry", defaultUserAgentTelemetry(), "Include the operating system and architecture in the User-Agent sent with requests to Sourcegraph"),
+		clientCert:         flagSet.String("client-cert", "", "Path to a PEM encoded client certificate to present to Sourcegraph, for mutual TLS. Requires -client-key"),
+		clientKey:          flagSet.String("client-key", "", "Path to the PEM encoded private key of the -client-cert certificate"),
+		caCert:             flagSet.String("cacert", "", "Path to a PEM encoded CA certificate bundle to trust in addition to the system roots, e.g. for a private CA"),
+		headers:            headers,
+		allowReserved:      flagSet.Bool("allow-reserved-headers", false, "Allow -header to override headers set by src, such as Authorization"),
 	}
 }
 
 func defaultFlags() *Flags {
 	telemetry := defaultUserAgentTelemetry()
 	d := false
+	empty := ""
 	return &Flags{
 		dump:               &d,
 		getCurl:            &d,
 		trace:              &d,
 		insecureSkipVerify: &d,
 		userAgentTelemetry: &telemetry,
+		clientCert:         &empty,
+		clientKey:          &empty,
+		caCert:             &empty,
+		headers:            &headerFlag{},
+		allowReserved:      &d,
 	}
 }
 
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/sourcegraph/src-cli/internal/version"
+)
+
+// versionCheckOnce ensures that the version of the instance is only checked on
+// the first request of the process, however many clients are created.
+var versionCheckOnce sync.Once
+
+// checkVersion warns on the client's Out if the version of src and that of the
+// instance are incompatible. Only the first call in the process checks the
+// version, and any error fetching the instance's version is ignored, since the
+// request that triggered the check will most likely report it.
+func (c *client) checkVersion(ctx context.Context) {
+	if !c.opts.VersionCheck || *c.opts.Flags.getCurl {
+		return
+	}
+	versionCheckOnce.Do(func() {
+		var result struct {
+			Site struct {
+				ProductVersion string
+			}
+		}
+		// Call do directly, since Do would check the version again.
+		r := &request{client: c, query: `query SrcCLIVersionCheck { site { productVersion } }`}
+		if ok, _, err := r.do(ctx, &rawResult{Data: &result}); err != nil || !ok {
+			return
+		}
+
+		instanceVersion := result.Site.ProductVersion
+		if versionsIncompatible(version.BuildTag, instanceVersion) {
+			fmt.Fprintf(c.opts.Out, "⚠️  Warning: src %s may not be compatible with the Sourcegraph instance at %s, which runs version %s. Install the version that 'src version' recommends, or pass -no-version-check to hide this warning.\n",
+				version.BuildTag, c.opts.Endpoint, instanceVersion)
+		}
+	})
+}
+
+var majorMinorPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.`)
+
+// lastMinorReleases maps past major versions of Sourcegraph to the minor version
+// of their last release.
+var lastMinorReleases = map[int]int{3: 43, 4: 5, 5: 11}
+
+// versionsIncompatible reports whether the src version is incompatible with the
+// instance version: src supports instances at most one release apart, where the
+// last release of a major version and the first of the next are adjacent.
+// Versions that aren't releases, such as dev builds and insiders instances, are
+// never reported.
+func versionsIncompatible(srcVersion, instanceVersion string) bool {
+	srcMajor, srcMinor, ok := parseMajorMinor(srcVersion)
+	if !ok {
+		return false
+	}
+	instanceMajor, instanceMinor, ok := parseMajorMinor(instanceVersion)
+	if !ok {
+		return false
+	}
+	return releasesBetween(srcMajor, srcMinor, instanceMajor, instanceMinor) > 1
+}
+
+// releasesBetween returns the number of minor releases from one version to the
+// other. The last release of a major version that isn't in lastMinorReleases is
+// assumed to be the older version, so that it is adjacent to the next major
+// version.
+func releasesBetween(major1, minor1, major2, minor2 int) int {
+	if major1 > major2 || (major1 == major2 && minor1 > minor2) {
+		major1, minor1, major2, minor2 = major2, minor2, major1, minor1
+	}
+	n := 0
+	for ; major1 < major2; major1, minor1 = major1+1, 0 {
+		last, ok := lastMinorReleases[major1]
+		if !ok || last < minor1 {
+			last = minor1
+		}
+		n += last - minor1 + 1
+	}
+	return n + minor2 - minor1
+}
+
+func parseMajorMinor(v string) (major, minor int, ok bool) {
+	m := majorMinorPattern.FindStringSubmatch(v)
+	if m == nil {
+		return 0, 0, false
+	}
+	major, _ = strconv.Atoi(m[1])
+	minor, _ = strconv.Atoi(m[2])
+	// 0.0.0 is the version of development builds of Sourcegraph.
+	return major, minor, major != 0
+}
@@ -0,0 +1,83 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/src-cli/internal/exec"
+)
+
+// ImageCheckError is returned by CheckImages for the images that could not be
+// found or accessed.
+type ImageCheckError struct {
+	// Images maps the name of each image to the reason it could not be found.
+	Images map[string]string
+}
+
+func (e *ImageCheckError) Error() string {
+	names := make([]string, 0, len(e.Images))
+	for name := range e.Images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("container images not found or not accessible:")
+	for _, name := range names {
+		b.WriteString("\n\t" + name + ": " + e.Images[name])
+	}
+	return b.String()
+}
+
+// CheckImages checks that each of the named images either exists locally or can
+// be resolved in its registry, without pulling it. This allows failing fast on
+// mistyped image names, rather than after pulling the other images. An
+// *ImageCheckError lists the images that could not be found.
+func CheckImages(ctx context.Context, names []string) error {
+	missing := map[string]string{}
+	for _, name := range names {
+		// Images that only exist locally can't be resolved in a registry, so
+		// check for them first.
+		if err := inspectImage(ctx, name); err == nil {
+			continue
+		} else if errors.HasType(err, &fastCommandTimeoutError{}) {
+			return err
+		}
+
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "docker", "manifest", "inspect", name)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			reason := strings.TrimSpace(stderr.String())
+			if reason == "" {
+				reason = err.Error()
+			}
+			missing[name] = reason
+		}
+	}
+
+	if len(missing) > 0 {
+		return &ImageCheckError{Images: missing}
+	}
+	return nil
+}
+
+// inspectImage returns an error if the image does not exist locally.
+func inspectImage(ctx context.Context, name string) error {
+	dctx, cancel, err := withFastCommandContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	args := []string{"image", "inspect", "--format", "{{ .Id }}", name}
+	err = exec.CommandContext(dctx, "docker", args...).Run()
+	if errors.IsDeadlineExceeded(err) || errors.IsDeadlineExceeded(dctx.Err()) {
+		return newFastCommandTimeoutError(dctx, args...)
+	}
+	return err
+}
@@ -0,0 +1,54 @@
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sourcegraph/src-cli/internal/exec/expect"
+)
+
+func TestCheckImages(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("local and remote images", func(t *testing.T) {
+		expect.Commands(
+			t,
+			imageInspect("local", 0),
+			imageInspect("remote", 1),
+			manifestInspect("remote", 0, ""),
+		)
+
+		assert.NoError(t, CheckImages(ctx, []string{"local", "remote"}))
+	})
+
+	t.Run("missing image", func(t *testing.T) {
+		expect.Commands(
+			t,
+			imageInspect("typo", 1),
+			manifestInspect("typo", 1, "no such manifest: docker.io/library/typo:latest\n"),
+			imageInspect("local", 0),
+		)
+
+		err := CheckImages(ctx, []string{"typo", "local"})
+		var checkErr *ImageCheckError
+		if assert.ErrorAs(t, err, &checkErr) {
+			assert.Equal(t, map[string]string{"typo": "no such manifest: docker.io/library/typo:latest"}, checkErr.Images)
+		}
+	})
+}
+
+func imageInspect(name string, exitCode int) *expect.Expectation {
+	return expect.NewLiteral(
+		expect.Behaviour{Stdout: []byte("sha256:abc\n"), ExitCode: exitCode},
+		"docker", "image", "inspect", "--format", "{{ .Id }}", name,
+	)
+}
+
+func manifestInspect(name string, exitCode int, stderr string) *expect.Expectation {
+	return expect.NewLiteral(
+		expect.Behaviour{Stderr: []byte(stderr), ExitCode: exitCode},
+		"docker", "manifest", "inspect", name,
+	)
+}
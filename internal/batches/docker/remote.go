@@ -0,0 +1,110 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/src-cli/internal/exec"
+)
+
+// IsRemoteDaemon returns true if the Docker daemon, as configured with DOCKER_HOST or
+// the current Docker context, is not on the local machine. Bind mounts refer to the
+// daemon's filesystem, so local files cannot be bind mounted into containers run by a
+// remote daemon.
+func IsRemoteDaemon(ctx context.Context) (bool, error) {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return isRemoteHost(host), nil
+	}
+
+	dctx, cancel, err := withFastCommandContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer cancel()
+
+	args := []string{"context", "inspect", "--format", "{{ .Endpoints.docker.Host }}"}
+	out, err := exec.CommandContext(dctx, "docker", args...).CombinedOutput()
+	if errors.IsDeadlineExceeded(err) || errors.IsDeadlineExceeded(dctx.Err()) {
+		return false, newFastCommandTimeoutError(dctx, args...)
+	} else if err != nil {
+		return false, errors.Wrapf(err, "inspecting Docker context: %s", out)
+	}
+
+	return isRemoteHost(string(bytes.TrimSpace(out))), nil
+}
+
+// isRemoteHost returns true if the given Docker host is reached over the network,
+// rather than a local socket or named pipe.
+func isRemoteHost(host string) bool {
+	u, err := url.Parse(host)
+	if err != nil || host == "" {
+		return false
+	}
+
+	switch u.Scheme {
+	case "unix", "npipe", "fd":
+		return false
+	default:
+		return true
+	}
+}
+
+// Mount is a local file or directory that should be available in a container.
+// Mounts are read-only unless ReadWrite is set.
+type Mount struct {
+	Source    string
+	Target    string
+	ReadWrite bool
+}
+
+// BindMountArgs returns the `docker run` arguments to bind mount the given
+// mounts.
+func BindMountArgs(mounts ...Mount) []string {
+	var args []string
+	for _, m := range mounts {
+		arg := "type=bind,source=" + m.Source + ",target=" + m.Target
+		if !m.ReadWrite {
+			arg += ",ro"
+		}
+		args = append(args, "--mount", arg)
+	}
+	return args
+}
+
+// CreateWithFiles creates a container with `docker create` and the given arguments,
+// which must end with the image and command, and then copies the mounts into the
+// container with `docker cp`. This is how files are made available to containers on
+// a remote Docker daemon. It returns the ID of the created container, which can then
+// be started with `docker start --attach`.
+func CreateWithFiles(ctx context.Context, args []string, mounts []Mount) (string, error) {
+	out, err := exec.CommandContext(ctx, "docker", append([]string{"create"}, args...)...).Output()
+	if err != nil {
+		return "", errors.Wrap(err, "creating container")
+	}
+	id := strings.TrimSpace(string(out))
+
+	for _, m := range mounts {
+		if out, err := exec.CommandContext(ctx, "docker", "cp", m.Source, id+":"+m.Target).CombinedOutput(); err != nil {
+			_ = exec.CommandContext(ctx, "docker", "rm", "-f", "--", id).Run()
+			return "", errors.Wrapf(err, "copying %s into container:\n\n%s", m.Source, string(out))
+		}
+	}
+
+	return id, nil
+}
+
+// RunWithFiles is the equivalent of `docker run` with the given arguments for a
+// remote Docker daemon: the container is created with CreateWithFiles, and then
+// started. The combined output of the container is returned.
+func RunWithFiles(ctx context.Context, args []string, mounts []Mount) ([]byte, error) {
+	id, err := CreateWithFiles(ctx, args, mounts)
+	if err != nil {
+		return nil, err
+	}
+	return exec.CommandContext(ctx, "docker", "start", "--attach", id).CombinedOutput()
+}
@@ -0,0 +1,36 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIsRemoteHost(t *testing.T) {
+	for host, want := range map[string]bool{
+		"":                               false,
+		"unix:///var/run/docker.sock":    false,
+		"npipe:////./pipe/docker_engine": false,
+		"tcp://docker:2376":              true,
+		"tcp://192.168.99.100:2376":      true,
+		"ssh://builder@ci.example.com":   true,
+	} {
+		if have := isRemoteHost(host); have != want {
+			t.Errorf("isRemoteHost(%q): have %v, want %v", host, have, want)
+		}
+	}
+}
+
+func TestBindMountArgs(t *testing.T) {
+	have := BindMountArgs(
+		Mount{Source: "/tmp/run.sh", Target: "/tmp/run.sh"},
+		Mount{Source: "/srv/lint", Target: "/lint", ReadWrite: true},
+	)
+	want := []string{
+		"--mount", "type=bind,source=/tmp/run.sh,target=/tmp/run.sh,ro",
+		"--mount", "type=bind,source=/srv/lint,target=/lint",
+	}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Errorf("wrong args (-want +have):\n%s", diff)
+	}
+}
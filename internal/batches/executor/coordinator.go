@@ -35,13 +35,20 @@ type NewCoordinatorOpts struct {
 	BinaryDiffs bool
 
 	IsRemote bool
+
+	// RunState, if set, records the tasks that complete, so that an interrupted
+	// execution can be resumed.
+	RunState *RunState
 }
 
 func NewCoordinator(opts NewCoordinatorOpts) *Coordinator {
-	return &Coordinator{
-		opts: opts,
-		exec: NewExecutor(opts.ExecOpts),
-	}
+	c := &Coordinator{opts: opts}
+	x := NewExecutor(opts.ExecOpts)
+	// Cache the results of each task as soon as it finishes, so that they
+	// aren't lost if the execution is interrupted.
+	x.onResult = c.cacheTaskResult
+	c.exec = x
+	return c
 }
 
 // CheckCache checks whether the internal ExecutionCache contains
@@ -77,6 +84,58 @@ func (c *Coordinator) ClearCache(ctx context.Context, tasks []*Task) error {
 	return nil
 }
 
+// CompletedTasks returns the number of tasks that the RunState records as
+// completed with the same step cache keys, meaning that their results are
+// cached.
+func (c *Coordinator) CompletedTasks(tasks []*Task) (int, error) {
+	if c.opts.RunState == nil {
+		return 0, nil
+	}
+	completed := 0
+	for _, task := range tasks {
+		keys, err := c.stepCacheKeys(task)
+		if err != nil {
+			return 0, err
+		}
+		if c.opts.RunState.completedWith(task, keys) {
+			completed++
+		}
+	}
+	return completed, nil
+}
+
+func (c *Coordinator) stepCacheKeys(task *Task) ([]string, error) {
+	keys := make([]string, 0, len(task.Steps))
+	for i := range task.Steps {
+		key, err := task.CacheKey(c.opts.GlobalEnv, c.opts.ExecOpts.WorkingDirectory, i).Key()
+		if err != nil {
+			return nil, errors.Wrapf(err, "calculating cache key for step %d in %q", i, task.Repository.Name)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// cacheTaskResult writes the step results of a task to the cache and, if the
+// task succeeded, records it in the RunState.
+func (c *Coordinator) cacheTaskResult(ctx context.Context, res taskResult) error {
+	for _, stepRes := range res.stepResults {
+		cacheKey := res.task.CacheKey(c.opts.GlobalEnv, c.opts.ExecOpts.WorkingDirectory, stepRes.StepIndex)
+		if err := c.opts.Cache.Set(ctx, cacheKey, stepRes); err != nil {
+			return errors.Wrapf(err, "caching result for step %d", stepRes.StepIndex)
+		}
+	}
+
+	if c.opts.RunState == nil || res.err != nil {
+		return nil
+	}
+	keys, err := c.stepCacheKeys(res.task)
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(c.opts.RunState.recordCompleted(res.task, keys), "recording run state")
+}
+
 func (c *Coordinator) checkCacheForTask(ctx context.Context, batchSpec *batcheslib.BatchSpec, task *Task) (specs []*batcheslib.ChangesetSpec, found bool, err error) {
 	if err := c.loadCachedStepResults(ctx, task, c.opts.GlobalEnv); err != nil {
 		return specs, false, err
@@ -184,13 +243,14 @@ func (c *Coordinator) ExecuteAndBuildSpecs(ctx context.Context, batchSpec *batch
 	c.exec.Start(ctx, tasks, ui)
 	results, errs := c.exec.Wait(ctx)
 
-	// Write all step cache results to the cache.
+	// Write the step results that weren't cached as their task finished to the
+	// cache.
 	for _, res := range results {
-		for _, stepRes := range res.stepResults {
-			cacheKey := res.task.CacheKey(c.opts.GlobalEnv, c.opts.ExecOpts.WorkingDirectory, stepRes.StepIndex)
-			if err := c.opts.Cache.Set(ctx, cacheKey, stepRes); err != nil {
-				return nil, nil, errors.Wrapf(err, "caching result for step %d", stepRes.StepIndex)
-			}
+		if res.cached {
+			continue
+		}
+		if err := c.cacheTaskResult(ctx, res); err != nil {
+			return nil, nil, err
 		}
 	}
 
@@ -90,7 +90,7 @@ func TestExecutionDiskCache_GetSet(t *testing.T) {
 	assertCacheMiss(t, cache, cacheKey1)
 }
 
-func assertCacheHit(t *testing.T, c ExecutionDiskCache, k cache.Keyer, want execution.AfterStepResult) {
+func assertCacheHit(t *testing.T, c cache.Cache, k cache.Keyer, want execution.AfterStepResult) {
 	t.Helper()
 
 	have, found, err := c.Get(context.Background(), k)
@@ -106,7 +106,7 @@ func assertCacheHit(t *testing.T, c ExecutionDiskCache, k cache.Keyer, want exec
 	}
 }
 
-func assertCacheMiss(t *testing.T, c ExecutionDiskCache, k cache.Keyer) {
+func assertCacheMiss(t *testing.T, c cache.Cache, k cache.Keyer) {
 	t.Helper()
 
 	_, found, err := c.Get(context.Background(), k)
@@ -50,6 +50,8 @@ type taskResult struct {
 	task        *Task
 	stepResults []execution.AfterStepResult
 	err         error
+	// cached is true if the step results were cached when the task finished.
+	cached bool
 }
 
 type imageEnsurer func(ctx context.Context, name string) (docker.Image, error)
@@ -69,6 +71,9 @@ type NewExecutorOpts struct {
 	IsRemote         bool
 	GlobalEnv        []string
 	ForceRoot        bool
+	RemoteDocker     bool
+	Secrets          map[string]string
+	Mounts           []docker.Mount
 
 	BinaryDiffs bool
 }
@@ -81,6 +86,9 @@ type executor struct {
 
 	results   []taskResult
 	resultsMu sync.Mutex
+
+	// onResult, if set, is called with the result of each task as it finishes.
+	onResult func(context.Context, taskResult) error
 }
 
 func NewExecutor(opts NewExecutorOpts) *executor {
@@ -182,6 +190,9 @@ func (x *executor) do(ctx context.Context, task *Task, ui TaskExecutionUI) (err
 		RepoArchive:      repoArchive,
 		WorkingDirectory: x.opts.WorkingDirectory,
 		ForceRoot:        x.opts.ForceRoot,
+		RemoteDocker:     x.opts.RemoteDocker,
+		Secrets:          x.opts.Secrets,
+		Mounts:           x.opts.Mounts,
 		BinaryDiffs:      x.opts.BinaryDiffs,
 
 		UI: ui.StepsExecutionUI(task),
@@ -196,18 +207,26 @@ func (x *executor) do(ctx context.Context, task *Task, ui TaskExecutionUI) (err
 		}
 		l.MarkErrored()
 	}
-	x.addResult(task, stepResults, err)
+	res := taskResult{
+		task:        task,
+		stepResults: stepResults,
+		err:         err,
+	}
+	if x.onResult != nil {
+		if resultErr := x.onResult(ctx, res); resultErr != nil {
+			err = errors.Append(err, resultErr)
+		} else {
+			res.cached = true
+		}
+	}
+	x.addResult(res)
 
 	return err
 }
 
-func (x *executor) addResult(task *Task, stepResults []execution.AfterStepResult, err error) {
+func (x *executor) addResult(res taskResult) {
 	x.resultsMu.Lock()
 	defer x.resultsMu.Unlock()
 
-	x.results = append(x.results, taskResult{
-		task:        task,
-		stepResults: stepResults,
-		err:         err,
-	})
+	x.results = append(x.results, res)
 }
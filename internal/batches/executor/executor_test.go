@@ -64,6 +64,7 @@ func TestExecutor_Integration(t *testing.T) {
 		tasks []*Task
 
 		executorTimeout time.Duration
+		secrets         map[string]string
 
 		wantFilesChanged  filesByRepository
 		wantTitle         string
@@ -316,6 +317,24 @@ func TestExecutor_Integration(t *testing.T) {
 			wantFinishedWithErr: 1,
 			wantCacheCount:      2,
 		},
+		{
+			name: "secret written to repository",
+			archives: []mock.RepoArchive{
+				{RepoName: testRepo1.Name, Commit: testRepo1.Rev(), Files: map[string]string{
+					"README.md": "# Welcome to the README\n",
+				}},
+			},
+			steps: []batcheslib.Step{
+				{Run: `echo "token: $SRC_TEST_SECRET" && echo "$SRC_TEST_SECRET" >> README.md`},
+			},
+			tasks: []*Task{
+				{Repository: testRepo1},
+			},
+			secrets:             map[string]string{"SRC_TEST_SECRET": "hunter2-secret-value"},
+			wantFilesChanged:    filesByRepository{},
+			wantErrInclude:      "step 1 wrote the value of secret SRC_TEST_SECRET to the repository",
+			wantFinishedWithErr: 1,
+		},
 		{
 			name: "mount path",
 			archives: []mock.RepoArchive{
@@ -376,7 +395,7 @@ func TestExecutor_Integration(t *testing.T) {
 			// Temp dir for log files and downloaded archives
 			testTempDir := t.TempDir()
 
-			cr, _ := workspace.NewCreator(context.Background(), "bind", testTempDir, testTempDir, images)
+			cr, _ := workspace.NewCreator(context.Background(), "bind", testTempDir, testTempDir, images, false)
 			// Setup executor
 			opts := NewExecutorOpts{
 				Creator:             cr,
@@ -387,6 +406,10 @@ func TestExecutor_Integration(t *testing.T) {
 				TempDir:     testTempDir,
 				Parallelism: runtime.GOMAXPROCS(0),
 				Timeout:     tc.executorTimeout,
+				Secrets:     tc.secrets,
+			}
+			for name, value := range tc.secrets {
+				t.Setenv(name, value)
 			}
 
 			if opts.Timeout == 0 {
@@ -797,7 +820,7 @@ func testExecuteTasks(t *testing.T, tasks []*Task, archives ...mock.RepoArchive)
 		}
 	}
 
-	cr, _ := workspace.NewCreator(context.Background(), "bind", testTempDir, testTempDir, images)
+	cr, _ := workspace.NewCreator(context.Background(), "bind", testTempDir, testTempDir, images, false)
 	// Setup executor
 	executor := NewExecutor(NewExecutorOpts{
 		Creator:             cr,
@@ -0,0 +1,182 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/sourcegraph/lib/batches/execution"
+	"github.com/sourcegraph/sourcegraph/lib/batches/execution/cache"
+)
+
+// NewRemoteCache returns a cache that shares execution results through the object
+// storage at the given URL, which must be of the form s3://bucket/prefix or
+// gs://bucket/prefix. Results are also read from and written to the local cache, so
+// results fetched from the remote cache are only downloaded once.
+//
+// S3 uses the AWS SDK's default credential chain, and Google Cloud Storage uses
+// Application Default Credentials.
+func NewRemoteCache(ctx context.Context, rawURL string, local cache.Cache) (cache.Cache, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing remote cache URL %q", rawURL)
+	}
+	if u.Host == "" {
+		return nil, errors.Newf("remote cache URL %q has no bucket", rawURL)
+	}
+
+	var store objectStore
+	switch u.Scheme {
+	case "s3":
+		store, err = newS3Store(ctx, u.Host)
+	case "gs":
+		store, err = newGCSStore(ctx, u.Host)
+	default:
+		return nil, errors.Newf("unsupported remote cache URL %q: scheme must be s3 or gs", rawURL)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating remote cache client for %q", rawURL)
+	}
+
+	return &ExecutionRemoteCache{
+		Local:  local,
+		Store:  store,
+		Prefix: strings.Trim(u.Path, "/"),
+	}, nil
+}
+
+// objectStore is the subset of object storage operations needed by
+// ExecutionRemoteCache.
+type objectStore interface {
+	// Get returns the contents of the object with the given key, and false if it
+	// does not exist.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Put(ctx context.Context, key string, data []byte) error
+	Delete(ctx context.Context, key string) error
+}
+
+// ExecutionRemoteCache is a cache that stores execution results in object storage,
+// using the same keys as ExecutionDiskCache, on top of a local cache.
+type ExecutionRemoteCache struct {
+	Local  cache.Cache
+	Store  objectStore
+	Prefix string
+}
+
+func (c *ExecutionRemoteCache) objectKey(key cache.Keyer) (string, error) {
+	keyString, err := key.Key()
+	if err != nil {
+		return "", errors.Wrap(err, "calculating execution cache key")
+	}
+
+	return path.Join(c.Prefix, key.Slug(), keyString+cacheFileExt), nil
+}
+
+func (c *ExecutionRemoteCache) Get(ctx context.Context, key cache.Keyer) (execution.AfterStepResult, bool, error) {
+	if result, found, err := c.Local.Get(ctx, key); err != nil || found {
+		return result, found, err
+	}
+
+	var result execution.AfterStepResult
+	objectKey, err := c.objectKey(key)
+	if err != nil {
+		return result, false, err
+	}
+
+	data, found, err := c.Store.Get(ctx, objectKey)
+	if err != nil {
+		return result, false, errors.Wrapf(err, "reading remote cache object %s", objectKey)
+	}
+	if !found {
+		return result, false, nil
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		// Unlike the disk cache, we don't delete invalid objects: another client
+		// may be in the middle of writing them.
+		return result, false, errors.Wrapf(err, "reading remote cache object %s", objectKey)
+	}
+
+	if err := c.Local.Set(ctx, key, result); err != nil {
+		return result, false, err
+	}
+	return result, true, nil
+}
+
+func (c *ExecutionRemoteCache) Set(ctx context.Context, key cache.Keyer, result execution.AfterStepResult) error {
+	if err := c.Local.Set(ctx, key, result); err != nil {
+		return err
+	}
+
+	objectKey, err := c.objectKey(key)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(&result)
+	if err != nil {
+		return errors.Wrap(err, "serializing cache content to JSON")
+	}
+	return errors.Wrapf(c.Store.Put(ctx, objectKey, raw), "writing remote cache object %s", objectKey)
+}
+
+func (c *ExecutionRemoteCache) Clear(ctx context.Context, key cache.Keyer) error {
+	if err := c.Local.Clear(ctx, key); err != nil {
+		return err
+	}
+
+	objectKey, err := c.objectKey(key)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(c.Store.Delete(ctx, objectKey), "deleting remote cache object %s", objectKey)
+}
+
+// gcsStore is an objectStore backed by a Google Cloud Storage bucket.
+type gcsStore struct {
+	bucket *storage.BucketHandle
+}
+
+func newGCSStore(ctx context.Context, bucket string) (*gcsStore, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &gcsStore{bucket: client.Bucket(bucket)}, nil
+}
+
+func (s *gcsStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	r, err := s.bucket.Object(key).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func (s *gcsStore) Put(ctx context.Context, key string, data []byte) error {
+	w := s.bucket.Object(key).NewWriter(ctx)
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (s *gcsStore) Delete(ctx context.Context, key string) error {
+	if err := s.bucket.Object(key).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// s3Store is an objectStore backed by an S3 bucket.
+type s3Store struct {
+	client *s3.Client
+	bucket string
+}
+
+// newS3Store creates an s3Store using the AWS SDK's default configuration, so
+// that credentials are found as by the AWS CLI: in the environment, the shared
+// configuration files (including AWS_PROFILE and SSO), web identity tokens (as
+// used by IRSA and OIDC federation in CI), and the container or instance
+// metadata. The region defaults to us-east-1 if none is configured.
+//
+// If AWS_ENDPOINT_URL is set, requests are sent to it instead, using path-style
+// addressing, which S3-compatible services generally require.
+func newS3Store(ctx context.Context, bucket string) (*s3Store, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading AWS configuration")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+			o.EndpointResolver = s3.EndpointResolverFromURL(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+	return &s3Store{client: client, bucket: bucket}, nil
+}
+
+func (s *s3Store) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if isS3NotFound(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if isS3NotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// isS3NotFound reports whether err is an S3 response with status 404, such as
+// the NoSuchKey error returned for objects that don't exist.
+func isS3NotFound(err error) bool {
+	var respErr *awshttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotFound
+}
@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/src-cli/internal/batches/util"
+)
+
+// RunState records which tasks of an execution of a batch spec have completed,
+// together with the cache keys of their steps, so that an interrupted execution
+// can be resumed. The file it is stored in is rewritten as each task completes.
+type RunState struct {
+	path string
+
+	mu        sync.Mutex
+	completed map[string][]string
+}
+
+type runStateFile struct {
+	// Completed maps the slugs of the completed tasks to the cache keys of
+	// their steps.
+	Completed map[string][]string `json:"completed"`
+}
+
+// RunStatePath returns the path of the file in cacheDir that records the state
+// of executions of the batch spec with the given raw contents.
+func RunStatePath(cacheDir string, rawSpec []byte) string {
+	sum := sha256.Sum256(rawSpec)
+	return filepath.Join(cacheDir, "runs", hex.EncodeToString(sum[:])+".json")
+}
+
+// NewRunState returns an empty RunState that replaces the file at path once
+// the first task completes.
+func NewRunState(path string) *RunState {
+	return &RunState{path: path, completed: map[string][]string{}}
+}
+
+// LoadRunState reads the RunState stored at path by a previous execution. The
+// returned error satisfies os.IsNotExist if there is none.
+func LoadRunState(path string) (*RunState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f runStateFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, errors.Wrapf(err, "reading run state %s", path)
+	}
+	if f.Completed == nil {
+		f.Completed = map[string][]string{}
+	}
+	return &RunState{path: path, completed: f.Completed}, nil
+}
+
+// Path returns the path of the file the RunState is stored in.
+func (s *RunState) Path() string { return s.path }
+
+// Remove deletes the file the RunState is stored in, if it exists.
+func (s *RunState) Remove() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// completedWith returns whether the task was recorded as completed with the
+// given step cache keys.
+func (s *RunState) completedWith(task *Task, keys []string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recorded, ok := s.completed[runStateSlug(task)]
+	if !ok || len(recorded) != len(keys) {
+		return false
+	}
+	for i := range keys {
+		if recorded[i] != keys[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// recordCompleted records that the task completed with the given step cache
+// keys and writes the RunState to its file.
+func (s *RunState) recordCompleted(task *Task, keys []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.completed[runStateSlug(task)] = keys
+
+	data, err := json.Marshal(runStateFile{Completed: s.completed})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	// Write to a temporary file first, so that an interruption never leaves a
+	// truncated state behind.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func runStateSlug(task *Task) string {
+	return util.SlugForPathInRepo(task.Repository.Name, task.Repository.Rev(), task.Path)
+}
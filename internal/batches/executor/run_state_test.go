@@ -0,0 +1,105 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	batcheslib "github.com/sourcegraph/sourcegraph/lib/batches"
+	"github.com/sourcegraph/sourcegraph/lib/batches/execution"
+	"github.com/sourcegraph/sourcegraph/lib/batches/template"
+
+	"github.com/sourcegraph/src-cli/internal/batches/mock"
+)
+
+func TestRunState(t *testing.T) {
+	path := RunStatePath(t.TempDir(), []byte("name: test"))
+	if _, err := LoadRunState(path); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist error, got %v", err)
+	}
+
+	task := &Task{Repository: testRepo1}
+	state := NewRunState(path)
+	if err := state.recordCompleted(task, []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadRunState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.completedWith(task, []string{"a", "b"}) {
+		t.Error("expected task to be completed")
+	}
+	if loaded.completedWith(task, []string{"a", "c"}) {
+		t.Error("expected task with changed step to not be completed")
+	}
+	if loaded.completedWith(&Task{Repository: testRepo1, Path: "sub"}, []string{"a", "b"}) {
+		t.Error("expected other workspace to not be completed")
+	}
+
+	if err := loaded.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected state file to be removed, got %v", err)
+	}
+	if err := loaded.Remove(); err != nil {
+		t.Errorf("unexpected error removing missing state: %s", err)
+	}
+}
+
+func TestCoordinator_RunState(t *testing.T) {
+	task := &Task{
+		Steps:                 []batcheslib.Step{{Run: `echo "one"`}, {Run: `echo "two"`}},
+		Repository:            testRepo1,
+		BatchChangeAttributes: &template.BatchChangeAttributes{},
+	}
+	failed := &Task{
+		Steps:                 []batcheslib.Step{{Run: `false`}},
+		Repository:            testRepo1,
+		Path:                  "failed",
+		BatchChangeAttributes: &template.BatchChangeAttributes{},
+	}
+
+	cache := newInMemoryExecutionCache()
+	coord := &Coordinator{
+		opts: NewCoordinatorOpts{
+			Cache:    cache,
+			Logger:   mock.LogNoOpManager{},
+			RunState: NewRunState(filepath.Join(t.TempDir(), "state.json")),
+		},
+	}
+
+	ctx := context.Background()
+	if err := coord.cacheTaskResult(ctx, taskResult{
+		task: task,
+		stepResults: []execution.AfterStepResult{
+			{StepIndex: 0, Diff: []byte(`step-0-diff`)},
+			{StepIndex: 1, Diff: []byte(`step-1-diff`)},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := coord.cacheTaskResult(ctx, taskResult{task: failed, err: context.Canceled}); err != nil {
+		t.Fatal(err)
+	}
+	assertCacheSize(t, cache, 2)
+
+	completed, err := coord.CompletedTasks([]*Task{task, failed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completed != 1 {
+		t.Errorf("unexpected number of completed tasks: have=%d want=1", completed)
+	}
+
+	// Changing a step invalidates the recorded completion.
+	task.Steps[1].Run = `echo "two modified"`
+	if completed, err = coord.CompletedTasks([]*Task{task}); err != nil {
+		t.Fatal(err)
+	} else if completed != 0 {
+		t.Errorf("unexpected number of completed tasks: have=%d want=0", completed)
+	}
+}
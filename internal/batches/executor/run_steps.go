@@ -18,6 +18,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/lib/batches/template"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 
+	"github.com/sourcegraph/src-cli/internal/batches/docker"
 	"github.com/sourcegraph/src-cli/internal/batches/log"
 	"github.com/sourcegraph/src-cli/internal/batches/repozip"
 	"github.com/sourcegraph/src-cli/internal/batches/util"
@@ -54,6 +55,16 @@ type RunStepsOpts struct {
 	// ForceRoot forces Docker containers to be run as root:root, rather than
 	// whatever the image's default user and group are.
 	ForceRoot bool
+	// RemoteDocker indicates that the Docker daemon is on a remote host, so files
+	// are copied into step containers with `docker cp` rather than bind mounted.
+	RemoteDocker bool
+	// Secrets maps the names of environment variables that are exposed to every
+	// step container to their values, which are redacted from the step output
+	// and must not appear in the diff. They are not part of the cache key.
+	Secrets map[string]string
+	// Mounts are additional local paths mounted into every step container. Like
+	// secrets, they are not part of the cache key.
+	Mounts []docker.Mount
 
 	BinaryDiffs bool
 }
@@ -190,6 +201,9 @@ func RunSteps(ctx context.Context, opts *RunStepsOpts) (stepResults []execution.
 		if err != nil {
 			return stepResults, errors.Wrap(err, "getting diff produced by step")
 		}
+		if name := secretIn(stepDiff, opts.Secrets); name != "" {
+			return stepResults, errors.Newf("step %d wrote the value of secret %s to the repository", i+1, name)
+		}
 
 		// Next parse the diff to determine which files were changed.
 		changes, err := git.ChangesInDiff(stepDiff)
@@ -318,40 +332,69 @@ func executeSingleStep(
 		scriptWorkDir = workDir + "/" + opts.Task.Path
 	}
 
+	// On a remote Docker daemon, bind mounts would refer to the daemon's
+	// filesystem, so mounts are copied into the container instead.
+	var copies []docker.Mount
+	mount := func(source, target string) []string {
+		m := docker.Mount{Source: source, Target: target}
+		if opts.RemoteDocker {
+			copies = append(copies, m)
+			return nil
+		}
+		return docker.BindMountArgs(m)
+	}
+
 	args := append([]string{
-		"run",
 		"--rm",
 		"--init",
 		"--cidfile", cidFile,
 		"--workdir", scriptWorkDir,
-		"--mount", fmt.Sprintf("type=bind,source=%s,target=%s,ro", runScriptFile, containerTemp),
-	}, workspaceOpts...)
+	}, mount(runScriptFile, containerTemp)...)
+	args = append(args, workspaceOpts...)
 
 	if opts.ForceRoot {
 		args = append(args, "--user", "0:0")
 	}
 
 	for target, source := range filesToMount {
-		args = append(args, "--mount", fmt.Sprintf("type=bind,source=%s,target=%s,ro", source.Name(), target))
+		args = append(args, mount(source.Name(), target)...)
 	}
 
 	// Mount any paths on the local system to the docker container. The paths have already been validated during parsing.
-	for _, mount := range step.Mount {
-		workspaceFilePath, err := getAbsoluteMountPath(opts.WorkingDirectory, mount.Path)
+	for _, m := range step.Mount {
+		workspaceFilePath, err := getAbsoluteMountPath(opts.WorkingDirectory, m.Path)
 		if err != nil {
 			return bytes.Buffer{}, bytes.Buffer{}, err
 		}
-		args = append(args, "--mount", fmt.Sprintf("type=bind,source=%s,target=%s,ro", workspaceFilePath, mount.Mountpoint))
+		args = append(args, mount(workspaceFilePath, m.Mountpoint)...)
+	}
+	for _, m := range opts.Mounts {
+		if opts.RemoteDocker {
+			copies = append(copies, m)
+		} else {
+			args = append(args, docker.BindMountArgs(m)...)
+		}
 	}
 
 	for k, v := range env {
 		args = append(args, "-e", k+"="+v)
 	}
+	args = append(args, secretArgs(opts.Secrets)...)
 
 	args = append(args, "--entrypoint", shell)
 
-	cmd := exec.CommandContext(ctx, "docker", args...)
-	cmd.Args = append(cmd.Args, "--", imageDigest, containerTemp)
+	var cmd *exec.Cmd
+	if opts.RemoteDocker {
+		id, err := docker.CreateWithFiles(ctx, append(args, "--", imageDigest, containerTemp), copies)
+		if err != nil {
+			opts.UI.StepPreparingFailed(stepIdx+1, err)
+			return bytes.Buffer{}, bytes.Buffer{}, err
+		}
+		cmd = exec.CommandContext(ctx, "docker", "start", "--attach", id)
+	} else {
+		cmd = exec.CommandContext(ctx, "docker", append([]string{"run"}, args...)...)
+		cmd.Args = append(cmd.Args, "--", imageDigest, containerTemp)
+	}
 	if dir := workspace.WorkDir(); dir != nil {
 		cmd.Dir = *dir
 	}
@@ -363,8 +406,20 @@ func executeSingleStep(
 		outputWriter.Close()
 	}()
 
-	stdoutWriter := io.MultiWriter(&stdout, outputWriter.StdoutWriter(), opts.Logger.PrefixWriter("stdout"))
-	stderrWriter := io.MultiWriter(&stderr, outputWriter.StderrWriter(), opts.Logger.PrefixWriter("stderr"))
+	var stdoutWriter, stderrWriter io.Writer
+	stdoutWriter = io.MultiWriter(&stdout, outputWriter.StdoutWriter(), opts.Logger.PrefixWriter("stdout"))
+	stderrWriter = io.MultiWriter(&stderr, outputWriter.StderrWriter(), opts.Logger.PrefixWriter("stderr"))
+	flushOutput := func() {}
+	if len(opts.Secrets) > 0 {
+		// Redact secrets before the output is displayed, logged, or cached.
+		stdoutRedactor := newRedactingWriter(stdoutWriter, opts.Secrets)
+		stderrRedactor := newRedactingWriter(stderrWriter, opts.Secrets)
+		flushOutput = func() {
+			_ = stdoutRedactor.Flush()
+			_ = stderrRedactor.Flush()
+		}
+		stdoutWriter, stderrWriter = stdoutRedactor, stderrRedactor
+	}
 
 	// Setup readers that pipe the output into the given buffers
 	wg, err := process.PipeOutput(ctx, cmd, stdoutWriter, stderrWriter)
@@ -403,6 +458,7 @@ func executeSingleStep(
 	// Wait for the readers, because the pipes used by PipeOutput under the
 	// hood are closed when the command exits.
 	wg.Wait()
+	flushOutput()
 
 	// Now wait for the command.
 	err = cmd.Wait()
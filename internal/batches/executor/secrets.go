@@ -0,0 +1,82 @@
+package executor
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+)
+
+// secretArgs returns the docker run arguments that expose the secrets to a step
+// container. Only the names of the secrets are passed, so that docker reads
+// their values from its environment, which it inherits from src, and the values
+// never appear in the command line.
+func secretArgs(secrets map[string]string) []string {
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var args []string
+	for _, name := range names {
+		args = append(args, "-e", name)
+	}
+	return args
+}
+
+// secretIn returns the name of a secret whose value is contained in data, or an
+// empty string if there is none.
+func secretIn(data []byte, secrets map[string]string) string {
+	for name, value := range secrets {
+		if value != "" && bytes.Contains(data, []byte(value)) {
+			return name
+		}
+	}
+	return ""
+}
+
+// redactingWriter replaces the values of secrets in the output written to it
+// with a placeholder naming the secret. Output is redacted line by line, and the
+// lines of multi-line values are redacted separately, so that a value split
+// across writes is still redacted. Flush must be called once all output has been
+// written.
+type redactingWriter struct {
+	w        io.Writer
+	replacer *strings.Replacer
+	buf      []byte
+}
+
+func newRedactingWriter(w io.Writer, secrets map[string]string) *redactingWriter {
+	var oldnew []string
+	for name, value := range secrets {
+		for _, line := range strings.Split(value, "\n") {
+			if line = strings.TrimSuffix(line, "\r"); line != "" {
+				oldnew = append(oldnew, line, "[REDACTED:"+name+"]")
+			}
+		}
+	}
+	return &redactingWriter{w: w, replacer: strings.NewReplacer(oldnew...)}
+}
+
+func (r *redactingWriter) Write(p []byte) (int, error) {
+	r.buf = append(r.buf, p...)
+	if i := bytes.LastIndexByte(r.buf, '\n'); i >= 0 {
+		if _, err := io.WriteString(r.w, r.replacer.Replace(string(r.buf[:i+1]))); err != nil {
+			return 0, err
+		}
+		r.buf = r.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes the redacted remainder of the output that did not end in a
+// newline.
+func (r *redactingWriter) Flush() error {
+	if len(r.buf) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(r.w, r.replacer.Replace(string(r.buf)))
+	r.buf = nil
+	return err
+}
@@ -0,0 +1,51 @@
+package executor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRedactingWriter(t *testing.T) {
+	secrets := map[string]string{
+		"TOKEN": "s3cr3t",
+		"KEY":   "-----BEGIN KEY-----\nabcdef\n-----END KEY-----",
+	}
+
+	var buf bytes.Buffer
+	w := newRedactingWriter(&buf, secrets)
+	for _, chunk := range []string{"token: s3", "cr3t\n", "abc", "def\nlast s3cr3t"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff := cmp.Diff("token: [REDACTED:TOKEN]\n[REDACTED:KEY]\n", buf.String()); diff != "" {
+		t.Errorf("unexpected output before flush (-want +got):\n%s", diff)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "token: [REDACTED:TOKEN]\n[REDACTED:KEY]\nlast [REDACTED:TOKEN]"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func TestSecretArgs(t *testing.T) {
+	args := secretArgs(map[string]string{"B": "value-b", "A": "value-a"})
+	if diff := cmp.Diff([]string{"-e", "A", "-e", "B"}, args); diff != "" {
+		t.Errorf("unexpected args (-want +got):\n%s", diff)
+	}
+}
+
+func TestSecretIn(t *testing.T) {
+	secrets := map[string]string{"TOKEN": "s3cr3t"}
+	if name := secretIn([]byte("+token: s3cr3t\n"), secrets); name != "TOKEN" {
+		t.Errorf("expected secret to be found, got %q", name)
+	}
+	if name := secretIn([]byte("+token: redacted\n"), secrets); name != "" {
+		t.Errorf("unexpected secret %q", name)
+	}
+}
@@ -27,6 +27,7 @@ type ExecUI interface {
 
 	CheckingCache()
 	CheckingCacheSuccess(cachedSpecsFound int, tasksToExecute int)
+	ResumingExecution(completedTasks, totalTasks int)
 
 	ExecutingTasks(verbose bool, parallelism int) executor.TaskExecutionUI
 	ExecutingTasksSkippingErrors(err error)
@@ -90,6 +90,11 @@ func (ui *JSONLines) CheckingCacheSuccess(cachedSpecsFound int, tasksToExecute i
 	})
 }
 
+func (ui *JSONLines) ResumingExecution(completedTasks, totalTasks int) {
+	// The JSON lines protocol has no event for this; the cache check reports
+	// the tasks that still need to be executed.
+}
+
 func (ui *JSONLines) ExecutingTasks(_ bool, _ int) executor.TaskExecutionUI {
 	return &taskExecutionJSONLines{
 		binaryDiffs: ui.BinaryDiffs,
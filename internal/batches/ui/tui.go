@@ -165,6 +165,10 @@ func (ui *TUI) CheckingCacheSuccess(cachedSpecsFound int, uncachedTasks int) {
 	}
 }
 
+func (ui *TUI) ResumingExecution(completedTasks, totalTasks int) {
+	ui.Out.WriteLine(output.Linef(batchSuccessEmoji, batchSuccessColor, "Resuming previous execution: %d of %d tasks already completed", completedTasks, totalTasks))
+}
+
 func (ui *TUI) ExecutingTasks(verbose bool, parallelism int) executor.TaskExecutionUI {
 	ui.progressPrinter = newTaskExecTUI(ui.Out, verbose, parallelism)
 	return ui.progressPrinter
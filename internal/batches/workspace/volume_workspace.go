@@ -26,6 +26,9 @@ type imageEnsurer func(ctx context.Context, image string) (docker.Image, error)
 type dockerVolumeWorkspaceCreator struct {
 	tempDir     string
 	EnsureImage imageEnsurer
+	// remote is true if the Docker daemon is remote, in which case files are copied
+	// into containers rather than bind mounted.
+	remote bool
 }
 
 var _ Creator = &dockerVolumeWorkspaceCreator{}
@@ -53,6 +56,7 @@ func (wc *dockerVolumeWorkspaceCreator) Create(ctx context.Context, repo *graphq
 		tempDir: wc.tempDir,
 		volume:  volume,
 		uidGid:  ug,
+		remote:  wc.remote,
 	}
 	if err := wc.unzipRepoIntoVolume(ctx, w, archive.Path()); err != nil {
 		return nil, errors.Wrap(err, "unzipping repo into workspace")
@@ -142,13 +146,13 @@ func (wc *dockerVolumeWorkspaceCreator) unzipRepoIntoVolume(ctx context.Context,
 	}
 
 	// Now we can unzip the archive as the user and clean up the temporary file.
+	mounts := []docker.Mount{{Source: zip, Target: "/tmp/zip"}}
 	opts = append([]string{
-		"run",
 		"--rm",
 		"--init",
 		"--workdir", "/work",
-		"--mount", "type=bind,source=" + zip + ",target=/tmp/zip,ro",
-	}, w.dockerRunOptsWithUser(w.uidGid, "/work")...)
+	}, w.mountArgs(mounts)...)
+	opts = append(opts, w.dockerRunOptsWithUser(w.uidGid, "/work")...)
 	opts = append(
 		opts,
 		DockerVolumeWorkspaceImage,
@@ -156,7 +160,7 @@ func (wc *dockerVolumeWorkspaceCreator) unzipRepoIntoVolume(ctx context.Context,
 		fmt.Sprintf("unzip /tmp/zip; rm /work/%s", dummy),
 	)
 
-	if out, err := exec.CommandContext(ctx, "docker", opts...).CombinedOutput(); err != nil {
+	if out, err := w.run(ctx, opts, mounts); err != nil {
 		return errors.Wrapf(err, "unzip output:\n\n%s\n\n", string(out))
 	}
 
@@ -169,7 +173,6 @@ func (wc *dockerVolumeWorkspaceCreator) copyFilesIntoVolumes(ctx context.Context
 	}
 
 	opts := append([]string{
-		"run",
 		"--rm",
 		"--init",
 		"--workdir", "/work",
@@ -182,16 +185,16 @@ func (wc *dockerVolumeWorkspaceCreator) copyFilesIntoVolumes(ctx context.Context
 	}
 	sort.Strings(names)
 
-	var copyCmds []string
+	var (
+		mounts   []docker.Mount
+		copyCmds []string
+	)
 	for _, name := range names {
-		localPath := files[name]
-		opts = append(opts, []string{
-			"--mount", "type=bind,source=" + localPath + ",target=/tmp/" + name + ",ro",
-		}...)
-
+		mounts = append(mounts, docker.Mount{Source: files[name], Target: "/tmp/" + name})
 		copyCmds = append(copyCmds, "cp /tmp/"+name+" /work/"+name)
 	}
 
+	opts = append(opts, w.mountArgs(mounts)...)
 	opts = append(
 		opts,
 		DockerVolumeWorkspaceImage,
@@ -199,7 +202,7 @@ func (wc *dockerVolumeWorkspaceCreator) copyFilesIntoVolumes(ctx context.Context
 		strings.Join(copyCmds, " && ")+";",
 	)
 
-	if out, err := exec.CommandContext(ctx, "docker", opts...).CombinedOutput(); err != nil {
+	if out, err := w.run(ctx, opts, mounts); err != nil {
 		return errors.Wrapf(err, "unzip output:\n\n%s\n\n", string(out))
 	}
 	return nil
@@ -213,6 +216,7 @@ type dockerVolumeWorkspace struct {
 	tempDir string
 	volume  string
 	uidGid  docker.UIDGID
+	remote  bool
 }
 
 var _ Workspace = &dockerVolumeWorkspace{}
@@ -317,16 +321,16 @@ func (w *dockerVolumeWorkspace) runScript(ctx context.Context, target, script st
 		return nil, errors.Wrap(err, "generating run options")
 	}
 
+	mounts := []docker.Mount{{Source: name, Target: "/run.sh"}}
 	opts := append([]string{
-		"run",
 		"--rm",
 		"--init",
 		"--workdir", target,
-		"--mount", "type=bind,source=" + name + ",target=/run.sh,ro",
-	}, common...)
+	}, w.mountArgs(mounts)...)
+	opts = append(opts, common...)
 	opts = append(opts, DockerVolumeWorkspaceImage, "sh", "/run.sh")
 
-	out, err := exec.CommandContext(ctx, "docker", opts...).CombinedOutput()
+	out, err := w.run(ctx, opts, mounts)
 	if err != nil {
 		return out, errors.Wrapf(err, "Docker output:\n\n%s\n\n", string(out))
 	}
@@ -334,6 +338,24 @@ func (w *dockerVolumeWorkspace) runScript(ctx context.Context, target, script st
 	return out, nil
 }
 
+// mountArgs returns the `docker run` arguments to bind mount the given mounts, unless
+// the Docker daemon is remote: then the mounts are copied into the container by run.
+func (w *dockerVolumeWorkspace) mountArgs(mounts []docker.Mount) []string {
+	if w.remote {
+		return nil
+	}
+	return docker.BindMountArgs(mounts...)
+}
+
+// run runs a container with the given `docker run` arguments, which must include the
+// mountArgs for the given mounts.
+func (w *dockerVolumeWorkspace) run(ctx context.Context, opts []string, mounts []docker.Mount) ([]byte, error) {
+	if w.remote {
+		return docker.RunWithFiles(ctx, opts, mounts)
+	}
+	return exec.CommandContext(ctx, "docker", append([]string{"run"}, opts...)...).CombinedOutput()
+}
+
 func (w *dockerVolumeWorkspace) dockerRunOptsWithUser(ug docker.UIDGID, target string) []string {
 	return []string{
 		"--user", ug.String(),
@@ -526,6 +526,39 @@ func TestVolumeWorkspace_ApplyDiff(t *testing.T) {
 	}
 }
 
+func TestVolumeWorkspace_ApplyDiffRemote(t *testing.T) {
+	// On a remote Docker daemon, the script is copied into the container rather
+	// than bind mounted.
+	ctx := context.Background()
+	w := &dockerVolumeWorkspace{volume: volumeID, remote: true}
+	containerID := "0123456789ab"
+
+	expect.Commands(
+		t,
+		expect.NewGlob(
+			expect.Behaviour{Stdout: []byte(containerID + "\n")},
+			"docker", "create", "--rm", "--init", "--workdir", "/work",
+			"--user", "0:0",
+			"--mount", "type=volume,source="+volumeID+",target=/work",
+			DockerVolumeWorkspaceImage,
+			"sh", "/run.sh",
+		),
+		expect.NewGlob(
+			expect.Behaviour{ExitCode: 0},
+			"docker", "cp", "*", containerID+":/run.sh",
+		),
+		expect.NewGlob(
+			expect.Behaviour{ExitCode: 0},
+			"docker", "start", "--attach", containerID,
+		),
+	)
+
+	err := w.ApplyDiff(ctx, []byte(`dummydiff`))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 func TestVolumeWorkspace_runScript(t *testing.T) {
 	// Since the above tests have thoroughly tested our error handling, this
 	// test just fills in the one logical gap we have in our test coverage: is
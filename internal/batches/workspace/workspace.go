@@ -54,12 +54,18 @@ const (
 	CreatorTypeVolume
 )
 
-func NewCreator(ctx context.Context, preference, cacheDir, tempDir string, images map[string]docker.Image) (Creator, CreatorType) {
+// NewCreator returns the Creator for the given preference ("bind", "volume", or
+// "auto"). remoteDocker indicates that the Docker daemon is on a remote host, where
+// the local workspace directories used by bind workspaces are not available, so
+// volume workspaces are used unless bind workspaces are explicitly requested.
+func NewCreator(ctx context.Context, preference, cacheDir, tempDir string, images map[string]docker.Image, remoteDocker bool) (Creator, CreatorType) {
 	var workspaceType CreatorType
 	if preference == "volume" {
 		workspaceType = CreatorTypeVolume
 	} else if preference == "bind" {
 		workspaceType = CreatorTypeBind
+	} else if remoteDocker {
+		workspaceType = CreatorTypeVolume
 	} else {
 		workspaceType = BestCreatorType(ctx, images)
 	}
@@ -72,7 +78,7 @@ func NewCreator(ctx context.Context, preference, cacheDir, tempDir string, image
 			}
 			return img, nil
 		}
-		return &dockerVolumeWorkspaceCreator{tempDir: tempDir, EnsureImage: ensureImage}, workspaceType
+		return &dockerVolumeWorkspaceCreator{tempDir: tempDir, EnsureImage: ensureImage, remote: remoteDocker}, workspaceType
 	}
 
 	return &dockerBindWorkspaceCreator{Dir: cacheDir}, workspaceType
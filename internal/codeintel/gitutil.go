@@ -43,6 +43,28 @@ func InferCommit() (string, error) {
 	return runGitCommand("rev-parse", "HEAD")
 }
 
+// IsCommitHash reports whether rev is a full 40-character hexadecimal commit hash.
+func IsCommitHash(rev string) bool {
+	if len(rev) != 40 {
+		return false
+	}
+	for _, c := range rev {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveCommit gets the 40-character hash of the given commit hash or ref, such as a
+// branch, tag, or abbreviated hash, from the git clone enclosing the working dir.
+func ResolveCommit(rev string) (string, error) {
+	if IsCommitHash(rev) {
+		return strings.ToLower(rev), nil
+	}
+	return runGitCommand("rev-parse", "--verify", rev+"^{commit}")
+}
+
 // InferRoot gets the path relative to the root of the git clone enclosing the given file path.
 func InferRoot(file string) (string, error) {
 	topLevel, err := runGitCommand("rev-parse", "--show-toplevel")
@@ -81,3 +81,34 @@ func TestInferRoot(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveCommit(t *testing.T) {
+	head, err := InferCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]string{
+		head:      head,
+		"HEAD":    head,
+		head[:12]: head,
+		"0123456789ABCDEF0123456789abcdef01234567": "0123456789abcdef0123456789abcdef01234567",
+	}
+
+	for input, expectedOutput := range testCases {
+		t.Run(fmt.Sprintf("input=%q", input), func(t *testing.T) {
+			commit, err := ResolveCommit(input)
+			if err != nil {
+				t.Fatalf("unexpected error resolving commit: %s", err)
+			}
+
+			if commit != expectedOutput {
+				t.Errorf("unexpected commit. want=%q have=%q", expectedOutput, commit)
+			}
+		})
+	}
+
+	if _, err := ResolveCommit("refs/heads/does-not-exist"); err == nil {
+		t.Error("expected error resolving unknown ref")
+	}
+}
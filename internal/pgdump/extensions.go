@@ -20,31 +20,54 @@ import (
 // operation, so when filtering is complete the more efficient io.Copy should be used
 // to perform the remainder of the copy from src to dst.
 func PartialCopyWithoutExtensions(dst io.Writer, src io.ReadSeeker, progressFn func(int64)) (int64, error) {
-	var (
-		reader = bufio.NewReader(src)
-		// position we have consumed up to, track separately because bufio.Reader may have
-		// read ahead on src. This allows us to reset src later.
-		consumed int64
-		// number of bytes we have actually written to dst - it should always be returned.
-		written int64
-		// set to true when we have done all our filtering
-		noMoreExtensions bool
-	)
+	// bufio.Reader may read ahead on src, so the position consumed up to is
+	// tracked separately to reset src later.
+	consumed, written, err := copyWithoutExtensions(dst, bufio.NewReader(src), progressFn)
+	if err != nil {
+		return written, err
+	}
 
-	for !noMoreExtensions {
+	// No more extensions - reset src to the last actual consumed position
+	_, err = src.Seek(consumed, io.SeekStart)
+	if err != nil {
+		return written, errors.Wrap(err, "reset src position")
+	}
+	return written, nil
+}
+
+// CopyWithoutExtensions copies a SQL database dump from src to dst while commenting
+// out EXTENSIONs-related statements, like PartialCopyWithoutExtensions, but copies all
+// of src, so that it can be used with sources that cannot seek, such as dumps read
+// through a gzip.Reader.
+func CopyWithoutExtensions(dst io.Writer, src io.Reader, progressFn func(int64)) (int64, error) {
+	reader := bufio.NewReader(src)
+	_, written, err := copyWithoutExtensions(dst, reader, progressFn)
+	if err == io.EOF {
+		return written, nil
+	} else if err != nil {
+		return written, err
+	}
+	rest, err := io.Copy(dst, reader)
+	return written + rest, err
+}
+
+// copyWithoutExtensions copies lines from reader to dst, commenting out EXTENSIONs-
+// related statements, up to and including the first CREATE TABLE statement. It returns
+// the number of bytes consumed from reader and written to dst, and io.EOF if reader
+// ends first.
+func copyWithoutExtensions(dst io.Writer, reader *bufio.Reader, progressFn func(int64)) (consumed, written int64, err error) {
+	for {
 		// Read up to a line, keeping track of our position in src
-		line, err := reader.ReadBytes('\n')
+		line, readErr := reader.ReadBytes('\n')
 		consumed += int64(len(line))
-		if err != nil {
-			return written, err
+		if readErr != nil && (readErr != io.EOF || len(line) == 0) {
+			return consumed, written, readErr
 		}
 
 		// Once we start seeing table creations, we are definitely done with extensions,
 		// so we can hand off the rest to the superior io.Copy implementation.
-		if bytes.HasPrefix(line, []byte("CREATE TABLE")) {
-			// we are done with extensions
-			noMoreExtensions = true
-		} else if bytes.HasPrefix(line, []byte("COMMENT ON EXTENSION")) {
+		noMoreExtensions := bytes.HasPrefix(line, []byte("CREATE TABLE"))
+		if !noMoreExtensions && bytes.HasPrefix(line, []byte("COMMENT ON EXTENSION")) {
 			// comment out this line
 			line = append([]byte("-- "), line...)
 		}
@@ -54,14 +77,13 @@ func PartialCopyWithoutExtensions(dst io.Writer, src io.ReadSeeker, progressFn f
 		written += int64(lineWritten)
 		progressFn(written)
 		if err != nil {
-			return written, err
+			return consumed, written, err
+		}
+		if readErr != nil {
+			return consumed, written, readErr
+		}
+		if noMoreExtensions {
+			return consumed, written, nil
 		}
 	}
-
-	// No more extensions - reset src to the last actual consumed position
-	_, err := src.Seek(consumed, io.SeekStart)
-	if err != nil {
-		return written, errors.Wrap(err, "reset src position")
-	}
-	return written, nil
 }
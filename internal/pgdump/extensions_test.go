@@ -2,10 +2,12 @@ package pgdump
 
 import (
 	"bytes"
+	"compress/gzip"
 	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/hexops/autogold"
@@ -68,3 +70,41 @@ CREATE TABLE bobhead (
 	...
 )`).Equal(t, dst.String())
 }
+
+func TestCopyWithoutExtensions(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, err := zw.Write([]byte(`CREATE EXTENSION foobar
+
+COMMENT ON EXTENSION barbaz
+
+CREATE TABLE robert (
+	...
+)
+
+COMMENT ON EXTENSION later`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	zr, err := gzip.NewReader(&compressed)
+	require.NoError(t, err)
+	var dst bytes.Buffer
+	_, err = CopyWithoutExtensions(&dst, zr, func(i int64) {})
+	require.NoError(t, err)
+
+	assert.Equal(t, `CREATE EXTENSION foobar
+
+-- COMMENT ON EXTENSION barbaz
+
+CREATE TABLE robert (
+	...
+)
+
+COMMENT ON EXTENSION later`, dst.String())
+
+	// Dumps without tables are copied entirely.
+	dst.Reset()
+	_, err = CopyWithoutExtensions(&dst, strings.NewReader("COMMENT ON EXTENSION barbaz"), func(i int64) {})
+	require.NoError(t, err)
+	assert.Equal(t, "-- COMMENT ON EXTENSION barbaz", dst.String())
+}
@@ -21,57 +21,286 @@ type Target struct {
 	// - in docker, the name of the database container, e.g. pgsql, codeintel-db, codeinsights-db
 	// - in k8s, the name of the deployment or statefulset, e.g. deploy/pgsql, sts/pgsql
 	// - in plain pg_dump, the server host or socket directory
+	// - in direct pg_dump, the hostname of a managed database, e.g. an AWS RDS endpoint
 	Target string `yaml:"target"`
+	// Port is the port of the database server, if it is not the default.
+	Port int `yaml:"port"`
 
 	DBName   string `yaml:"dbname"`
 	Username string `yaml:"username"`
 
 	// Only include password if non-sensitive
 	Password string `yaml:"password"`
+	// PasswordCommand is a shell command that prints the password, e.g. to generate
+	// an IAM authentication token for AWS RDS. It is run by the shell that runs the
+	// pg_dump command, and cannot be combined with Password.
+	PasswordCommand string `yaml:"password_command"`
 }
 
 // Command generates a pg_dump command that can be used for on-prem-to-Cloud migrations.
 func Command(t Target) string {
 	dump := fmt.Sprintf("pg_dump --no-owner --format=p --no-acl --username=%s --dbname=%s",
 		t.Username, t.DBName)
-	if t.Password == "" {
+	switch {
+	case t.PasswordCommand != "":
+		return fmt.Sprintf(`PGPASSWORD="$(%s)" %s`, t.PasswordCommand, dump)
+	case t.Password != "":
+		return fmt.Sprintf("PGPASSWORD=%s %s", t.Password, dump)
+	default:
 		return dump
 	}
-	return fmt.Sprintf("PGPASSWORD=%s %s", t.Password, dump)
 }
 
+// DirectCommand generates a pg_dump command that connects to the database server
+// over the network from the machine it is run on, for databases that cannot be
+// reached through a container, such as managed Postgres instances. Target must be
+// the hostname of the server.
+func DirectCommand(t Target) (string, error) {
+	if t.Target == "" {
+		return "", errors.New("target must be set to the hostname of the database server")
+	}
+	if t.Password != "" && t.PasswordCommand != "" {
+		return "", errors.New("only one of password and password_command may be set")
+	}
+	cmd := fmt.Sprintf("%s --host=%s", Command(t), t.Target)
+	if t.Port != 0 {
+		cmd = fmt.Sprintf("%s --port=%d", cmd, t.Port)
+	}
+	return cmd, nil
+}
+
+// Builders are the names of the command builders returned by Builder.
+var Builders = []string{"pg_dump", "direct", "docker", "kubectl"}
+
+// Builder returns the CommandBuilder with the given name, one of Builders:
+//
+// - pg_dump runs pg_dump on this machine, against the Target host if it is set
+// - direct runs pg_dump on this machine against a server over the network, see DirectCommand
+// - docker runs pg_dump in the Target container with 'docker exec'
+// - kubectl runs pg_dump in the Target deployment or pod with 'kubectl exec'
+//
+// An empty name is pg_dump. The pg_dump command of each target is passed through
+// withOptions, e.g. to apply ModeCommand and CompressCommand, before docker and
+// kubectl wrap it in a remote shell. They do not allocate a TTY, which would mangle
+// the dump output.
+func Builder(name string, withOptions func(string) string) (CommandBuilder, error) {
+	switch name {
+	case "pg_dump", "":
+		return func(t Target) (string, error) {
+			cmd := Command(t)
+			if t.Target != "" {
+				cmd = fmt.Sprintf("%s --host=%s", cmd, t.Target)
+			}
+			if t.Port != 0 {
+				cmd = fmt.Sprintf("%s --port=%d", cmd, t.Port)
+			}
+			return withOptions(cmd), nil
+		}, nil
+	case "direct":
+		return func(t Target) (string, error) {
+			cmd, err := DirectCommand(t)
+			if err != nil {
+				return "", err
+			}
+			return withOptions(cmd), nil
+		}, nil
+	case "docker":
+		return func(t Target) (string, error) {
+			if t.PasswordCommand != "" {
+				return "", errors.New("password_command is not supported by the docker builder")
+			}
+			return fmt.Sprintf("docker exec -i %s sh -c '%s'", t.Target, withOptions(Command(t))), nil
+		}, nil
+	case "kubectl":
+		return func(t Target) (string, error) {
+			if t.PasswordCommand != "" {
+				return "", errors.New("password_command is not supported by the kubectl builder")
+			}
+			return fmt.Sprintf("kubectl exec -i %s -- bash -c '%s'", t.Target, withOptions(Command(t))), nil
+		}, nil
+	default:
+		return nil, errors.Newf("unknown builder %q, must be one of %v", name, Builders)
+	}
+}
+
+// Mode selects which parts of a database are dumped.
+type Mode string
+
+const (
+	// ModeFull dumps both the schema and the data.
+	ModeFull Mode = "full"
+	// ModeSchemaOnly dumps only the schema, e.g. to bootstrap an empty environment.
+	ModeSchemaOnly Mode = "schema-only"
+	// ModeDataOnly dumps only the data, e.g. to seed a database that already has
+	// the schema.
+	ModeDataOnly Mode = "data-only"
+)
+
+// Modes are the valid values of Mode.
+var Modes = []Mode{ModeFull, ModeSchemaOnly, ModeDataOnly}
+
+// ParseMode parses the name of a Mode. An empty name is ModeFull.
+func ParseMode(name string) (Mode, error) {
+	if name == "" {
+		return ModeFull, nil
+	}
+	for _, m := range Modes {
+		if Mode(name) == m {
+			return m, nil
+		}
+	}
+	return "", errors.Newf("unknown mode %q, must be one of %v", name, Modes)
+}
+
+// ModeCommand adds the pg_dump flag for mode to cmd, typically generated with
+// Command or DirectCommand. Like CompressCommand, it must be applied to the dump
+// command itself, before it is wrapped by a remote shell.
+func ModeCommand(cmd string, mode Mode) string {
+	switch mode {
+	case ModeSchemaOnly:
+		return cmd + " --schema-only"
+	case ModeDataOnly:
+		return cmd + " --data-only"
+	default:
+		return cmd
+	}
+}
+
+// ParallelCommands runs the commands generated by BuildCommands concurrently, by
+// starting each in the background and waiting for all of them. It is only meant for
+// commands that run pg_dump locally: running several interactive remote shells, such
+// as with 'docker exec', in the background does not work. Fewer than two commands
+// are returned unchanged.
+func ParallelCommands(commands []string) []string {
+	if len(commands) < 2 {
+		return commands
+	}
+	parallel := make([]string, 0, len(commands)+1)
+	for _, c := range commands {
+		parallel = append(parallel, c+" &")
+	}
+	return append(parallel, "wait")
+}
+
+// CompressedExtension is appended to the output paths of dumps compressed with
+// CompressCommand.
+const CompressedExtension = ".gz"
+
+// CompressCommand pipes the output of cmd, typically generated with Command, through
+// gzip. Command builders that run the dump in a remote shell should wrap the compressed
+// command so that compression happens before the data leaves the database host.
+func CompressCommand(cmd string) string {
+	return cmd + " | gzip"
+}
+
+// Databases are the names of Sourcegraph's databases, as used for the fields of
+// Targets in targets files.
+var Databases = []string{"primary", "codeintel", "codeinsights"}
+
 type Output struct {
-	Output string
-	Target Target
+	// Database is the name of the database, one of Databases.
+	Database string
+	Output   string
+	Target   Target
 }
 
 // Outputs generates a set of mappings between a pgdump.Target and the desired output
 // path. It can be provided a zero-value Targets to just generate the output paths.
 func Outputs(dir string, targets Targets) []Output {
 	return []Output{{
-		Output: filepath.Join(dir, "primary.sql"),
-		Target: targets.Primary,
+		Database: "primary",
+		Output:   filepath.Join(dir, "primary.sql"),
+		Target:   targets.Primary,
 	}, {
-		Output: filepath.Join(dir, "codeintel.sql"),
-		Target: targets.CodeIntel,
+		Database: "codeintel",
+		Output:   filepath.Join(dir, "codeintel.sql"),
+		Target:   targets.CodeIntel,
 	}, {
-		Output: filepath.Join(dir, "codeinsights.sql"),
-		Target: targets.CodeInsights,
+		Database: "codeinsights",
+		Output:   filepath.Join(dir, "codeinsights.sql"),
+		Target:   targets.CodeInsights,
 	}}
 }
 
 type CommandBuilder func(Target) (string, error)
 
+// BuildOptions configures the commands generated by BuildCommands.
+type BuildOptions struct {
+	// Compressed indicates the commands generated by the CommandBuilder compress their
+	// output with CompressCommand, so output files are named with CompressedExtension.
+	Compressed bool
+
+	// Only, if set, limits the commands to the named databases.
+	Only []string
+	// Skip omits commands for the named databases.
+	Skip []string
+}
+
+// Databases returns the names of the databases selected by Only and Skip, in the
+// order of Databases. It returns an error if an unknown database is named, or if
+// no database is selected.
+func (o BuildOptions) Databases() ([]string, error) {
+	known := map[string]bool{}
+	for _, db := range Databases {
+		known[db] = true
+	}
+	only := map[string]bool{}
+	for _, db := range o.Only {
+		if !known[db] {
+			return nil, errors.Newf("unknown database %q, must be one of %v", db, Databases)
+		}
+		only[db] = true
+	}
+	skip := map[string]bool{}
+	for _, db := range o.Skip {
+		if !known[db] {
+			return nil, errors.Newf("unknown database %q, must be one of %v", db, Databases)
+		}
+		skip[db] = true
+	}
+
+	var selected []string
+	for _, db := range Databases {
+		if len(only) > 0 && !only[db] {
+			continue
+		}
+		if skip[db] {
+			continue
+		}
+		selected = append(selected, db)
+	}
+	if len(selected) == 0 {
+		return nil, errors.New("no databases selected")
+	}
+	return selected, nil
+}
+
 // BuildCommands generates commands that output Postgres dumps and sends them to predefined
-// files for each target database.
-func BuildCommands(outDir string, commandBuilder CommandBuilder, targets Targets) ([]string, error) {
+// files for each target database selected by opts.
+func BuildCommands(outDir string, commandBuilder CommandBuilder, targets Targets, opts BuildOptions) ([]string, error) {
+	databases, err := opts.Databases()
+	if err != nil {
+		return nil, err
+	}
+	selected := map[string]bool{}
+	for _, db := range databases {
+		selected[db] = true
+	}
+
 	var commands []string
 	for _, t := range Outputs(outDir, targets) {
+		if !selected[t.Database] {
+			continue
+		}
+		output := t.Output
+		if opts.Compressed {
+			output += CompressedExtension
+		}
 		c, err := commandBuilder(t.Target)
 		if err != nil {
-			return nil, errors.Wrapf(err, "generating command for %q", t.Output)
+			return nil, errors.Wrapf(err, "generating command for %q", output)
 		}
-		commands = append(commands, fmt.Sprintf("%s > %s", c, t.Output))
+		commands = append(commands, fmt.Sprintf("%s > %s", c, output))
 	}
 	return commands, nil
 }
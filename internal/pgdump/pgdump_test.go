@@ -0,0 +1,227 @@
+package pgdump
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuildCommands(t *testing.T) {
+	targets := Targets{
+		Primary:      Target{Target: "pgsql", DBName: "sg", Username: "sg"},
+		CodeIntel:    Target{Target: "codeintel-db", DBName: "sg", Username: "sg"},
+		CodeInsights: Target{Target: "codeinsights-db", Port: 5433, DBName: "postgres", Username: "postgres"},
+	}
+	noOptions := func(cmd string) string { return cmd }
+
+	for name, tc := range map[string]struct {
+		builder     string
+		withOptions func(string) string
+		opts        BuildOptions
+		want        []string
+	}{
+		"pg_dump": {
+			builder: "pg_dump",
+			want: []string{
+				"pg_dump --no-owner --format=p --no-acl --username=sg --dbname=sg --host=pgsql > out/primary.sql",
+				"pg_dump --no-owner --format=p --no-acl --username=sg --dbname=sg --host=codeintel-db > out/codeintel.sql",
+				"pg_dump --no-owner --format=p --no-acl --username=postgres --dbname=postgres --host=codeinsights-db --port=5433 > out/codeinsights.sql",
+			},
+		},
+		"direct": {
+			builder: "direct",
+			want: []string{
+				"pg_dump --no-owner --format=p --no-acl --username=sg --dbname=sg --host=pgsql > out/primary.sql",
+				"pg_dump --no-owner --format=p --no-acl --username=sg --dbname=sg --host=codeintel-db > out/codeintel.sql",
+				"pg_dump --no-owner --format=p --no-acl --username=postgres --dbname=postgres --host=codeinsights-db --port=5433 > out/codeinsights.sql",
+			},
+		},
+		"docker": {
+			builder: "docker",
+			want: []string{
+				"docker exec -i pgsql sh -c 'pg_dump --no-owner --format=p --no-acl --username=sg --dbname=sg' > out/primary.sql",
+				"docker exec -i codeintel-db sh -c 'pg_dump --no-owner --format=p --no-acl --username=sg --dbname=sg' > out/codeintel.sql",
+				"docker exec -i codeinsights-db sh -c 'pg_dump --no-owner --format=p --no-acl --username=postgres --dbname=postgres' > out/codeinsights.sql",
+			},
+		},
+		"docker compressed": {
+			builder:     "docker",
+			withOptions: CompressCommand,
+			opts:        BuildOptions{Compressed: true},
+			want: []string{
+				"docker exec -i pgsql sh -c 'pg_dump --no-owner --format=p --no-acl --username=sg --dbname=sg | gzip' > out/primary.sql.gz",
+				"docker exec -i codeintel-db sh -c 'pg_dump --no-owner --format=p --no-acl --username=sg --dbname=sg | gzip' > out/codeintel.sql.gz",
+				"docker exec -i codeinsights-db sh -c 'pg_dump --no-owner --format=p --no-acl --username=postgres --dbname=postgres | gzip' > out/codeinsights.sql.gz",
+			},
+		},
+		"kubectl": {
+			builder: "kubectl",
+			want: []string{
+				"kubectl exec -i pgsql -- bash -c 'pg_dump --no-owner --format=p --no-acl --username=sg --dbname=sg' > out/primary.sql",
+				"kubectl exec -i codeintel-db -- bash -c 'pg_dump --no-owner --format=p --no-acl --username=sg --dbname=sg' > out/codeintel.sql",
+				"kubectl exec -i codeinsights-db -- bash -c 'pg_dump --no-owner --format=p --no-acl --username=postgres --dbname=postgres' > out/codeinsights.sql",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			withOptions := tc.withOptions
+			if withOptions == nil {
+				withOptions = noOptions
+			}
+			builder, err := Builder(tc.builder, withOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			commands, err := BuildCommands("out", builder, targets, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, commands); diff != "" {
+				t.Errorf("unexpected commands (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("password command", func(t *testing.T) {
+		withPasswordCommand := Targets{Primary: Target{Target: "pgsql", DBName: "sg", Username: "sg", PasswordCommand: "echo sg"}}
+		for _, name := range []string{"docker", "kubectl"} {
+			builder, err := Builder(name, noOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := BuildCommands("out", builder, withPasswordCommand, BuildOptions{Only: []string{"primary"}}); err == nil {
+				t.Errorf("%s: expected error for password_command", name)
+			}
+		}
+	})
+
+	t.Run("unknown builder", func(t *testing.T) {
+		if _, err := Builder("ssh", noOptions); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("selected databases", func(t *testing.T) {
+		dockerBuilder, err := Builder("docker", noOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, tc := range map[string]struct {
+			opts    BuildOptions
+			want    []string
+			wantErr bool
+		}{
+			"only": {
+				opts: BuildOptions{Only: []string{"codeinsights", "primary"}},
+				want: []string{
+					"docker exec -i pgsql sh -c 'pg_dump --no-owner --format=p --no-acl --username=sg --dbname=sg' > out/primary.sql",
+					"docker exec -i codeinsights-db sh -c 'pg_dump --no-owner --format=p --no-acl --username=postgres --dbname=postgres' > out/codeinsights.sql",
+				},
+			},
+			"skip": {
+				opts: BuildOptions{Skip: []string{"primary", "codeintel"}},
+				want: []string{
+					"docker exec -i codeinsights-db sh -c 'pg_dump --no-owner --format=p --no-acl --username=postgres --dbname=postgres' > out/codeinsights.sql",
+				},
+			},
+			"unknown database": {
+				opts:    BuildOptions{Only: []string{"frontend"}},
+				wantErr: true,
+			},
+			"nothing selected": {
+				opts:    BuildOptions{Only: []string{"primary"}, Skip: []string{"primary"}},
+				wantErr: true,
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				commands, err := BuildCommands("out", dockerBuilder, targets, tc.opts)
+				if (err != nil) != tc.wantErr {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if diff := cmp.Diff(tc.want, commands); diff != "" {
+					t.Errorf("unexpected commands (-want +got):\n%s", diff)
+				}
+			})
+		}
+	})
+}
+
+func TestDirectCommand(t *testing.T) {
+	for name, tc := range map[string]struct {
+		target  Target
+		want    string
+		wantErr bool
+	}{
+		"host": {
+			target: Target{Target: "db.example.com", DBName: "sg", Username: "sg"},
+			want:   "pg_dump --no-owner --format=p --no-acl --username=sg --dbname=sg --host=db.example.com",
+		},
+		"host and port": {
+			target: Target{Target: "db.example.com", Port: 5433, DBName: "sg", Username: "sg", Password: "sg"},
+			want:   "PGPASSWORD=sg pg_dump --no-owner --format=p --no-acl --username=sg --dbname=sg --host=db.example.com --port=5433",
+		},
+		"password command": {
+			target: Target{
+				Target:          "db.example.com",
+				Port:            5432,
+				DBName:          "postgres",
+				Username:        "sourcegraph",
+				PasswordCommand: "aws rds generate-db-auth-token --hostname db.example.com --port 5432 --username sourcegraph",
+			},
+			want: `PGPASSWORD="$(aws rds generate-db-auth-token --hostname db.example.com --port 5432 --username sourcegraph)" pg_dump --no-owner --format=p --no-acl --username=sourcegraph --dbname=postgres --host=db.example.com --port=5432`,
+		},
+		"no host": {
+			target:  Target{DBName: "sg", Username: "sg"},
+			wantErr: true,
+		},
+		"password and password command": {
+			target:  Target{Target: "db.example.com", DBName: "sg", Username: "sg", Password: "sg", PasswordCommand: "echo sg"},
+			wantErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := DirectCommand(tc.target)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("unexpected command:\nwant: %s\ngot:  %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestModeCommand(t *testing.T) {
+	cmd := Command(Target{DBName: "sg", Username: "sg"})
+	for name, want := range map[string]string{
+		"":            cmd,
+		"full":        cmd,
+		"schema-only": cmd + " --schema-only",
+		"data-only":   cmd + " --data-only",
+	} {
+		mode, err := ParseMode(name)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", name, err)
+		}
+		if got := ModeCommand(cmd, mode); got != want {
+			t.Errorf("%q: unexpected command:\nwant: %s\ngot:  %s", name, want, got)
+		}
+	}
+
+	if _, err := ParseMode("schema"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestParallelCommands(t *testing.T) {
+	commands := []string{"pg_dump a > a.sql", "pg_dump b | gzip > b.sql.gz"}
+	want := []string{"pg_dump a > a.sql &", "pg_dump b | gzip > b.sql.gz &", "wait"}
+	if diff := cmp.Diff(want, ParallelCommands(commands)); diff != "" {
+		t.Errorf("unexpected commands (-want +got):\n%s", diff)
+	}
+
+	single := []string{"pg_dump a > a.sql"}
+	if diff := cmp.Diff(single, ParallelCommands(single)); diff != "" {
+		t.Errorf("unexpected commands (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,75 @@
+package pgdump
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ParseTargets decodes a targets file. Unknown fields are rejected, and each of
+// the given databases must be configured with a database name and username.
+// Other databases may be omitted. Errors refer to the offending line of the file.
+//
+// The password is optional, since pg_dump can also read it from a password
+// file or prompt for it.
+func ParseTargets(data []byte, databases []string) (Targets, error) {
+	var targets Targets
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&targets); err != nil {
+		if err == io.EOF {
+			return targets, errors.New("no databases configured")
+		}
+		return targets, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return targets, err
+	}
+	return targets, validateTargets(&doc, databases)
+}
+
+// validateTargets checks that each of the given databases in the decoded targets
+// document has the fields required to generate a pg_dump command.
+func validateTargets(doc *yaml.Node, databases []string) error {
+	root := doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+
+	var errs errors.MultiError
+	for _, database := range databases {
+		key, target := mappingValue(root, database)
+		if target == nil {
+			errs = errors.Append(errs, errors.Newf("line %d: %s: database is not configured", root.Line, database))
+			continue
+		}
+		for _, field := range []string{"dbname", "username"} {
+			fieldKey, value := mappingValue(target, field)
+			switch {
+			case value == nil:
+				errs = errors.Append(errs, errors.Newf("line %d: %s: %s must be set", key.Line, database, field))
+			case value.Kind != yaml.ScalarNode || value.Value == "":
+				errs = errors.Append(errs, errors.Newf("line %d: %s: %s must not be empty", fieldKey.Line, database, field))
+			}
+		}
+	}
+	return errs
+}
+
+// mappingValue returns the key and value nodes for the given key in the mapping
+// node, or nil if the node is not a mapping or does not contain the key.
+func mappingValue(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
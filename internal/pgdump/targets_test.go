@@ -0,0 +1,94 @@
+package pgdump
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseTargets(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		targets, err := ParseTargets([]byte(`
+primary:
+  target: pgsql
+  dbname: sg
+  username: sg
+  password: sg
+codeintel:
+  target: codeintel-db
+  dbname: sg
+  username: sg
+codeinsights:
+  target: codeinsights-db
+  dbname: postgres
+  username: postgres
+`), Databases)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Targets{
+			Primary:      Target{Target: "pgsql", DBName: "sg", Username: "sg", Password: "sg"},
+			CodeIntel:    Target{Target: "codeintel-db", DBName: "sg", Username: "sg"},
+			CodeInsights: Target{Target: "codeinsights-db", DBName: "postgres", Username: "postgres"},
+		}
+		if diff := cmp.Diff(want, targets); diff != "" {
+			t.Errorf("unexpected targets (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("only selected databases validated", func(t *testing.T) {
+		_, err := ParseTargets([]byte(`
+codeinsights:
+  dbname: postgres
+  username: postgres
+`), []string{"codeinsights"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		data    string
+		wantErr []string
+	}{
+		"empty": {
+			data:    "",
+			wantErr: []string{"no databases configured"},
+		},
+		"unknown field": {
+			data: `
+primary:
+  dbanme: sg
+  username: sg
+`,
+			wantErr: []string{"line 3: field dbanme not found"},
+		},
+		"missing fields": {
+			data: `
+primary:
+  dbname: sg
+codeintel:
+  dbname: ""
+  username: sg
+`,
+			wantErr: []string{
+				"line 2: primary: username must be set",
+				"line 5: codeintel: dbname must not be empty",
+				"codeinsights: database is not configured",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseTargets([]byte(tc.data), Databases)
+			if err == nil {
+				t.Fatal("unexpected nil error")
+			}
+			for _, want := range tc.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
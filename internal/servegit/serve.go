@@ -2,6 +2,7 @@ package servegit
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -13,6 +14,7 @@ import (
 	pathpkg "path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sourcegraph/sourcegraph/lib/errors"
@@ -24,6 +26,23 @@ type Serve struct {
 	Root  string
 	Info  *log.Logger
 	Debug *log.Logger
+
+	// Username and Password, if set, are the HTTP basic auth credentials
+	// clients must present. Requests without matching credentials are
+	// rejected with 401 Unauthorized.
+	Username string
+	Password string
+
+	// WatchInterval, if set, is how often Root is re-scanned for added or
+	// removed repositories. Root is polled rather than watched with filesystem
+	// notifications, which don't reliably report changes in nested directories
+	// or on network filesystems. While watching, only repositories found by the last
+	// scan that have a commit checked out are listed and served, so that
+	// repositories that are still being cloned are not served.
+	WatchInterval time.Duration
+
+	mu    sync.RWMutex
+	repos []Repo
 }
 
 func (s *Serve) Start() error {
@@ -37,6 +56,18 @@ func (s *Serve) Start() error {
 
 	s.Info.Printf("listening on http://%s", s.Addr)
 	s.Info.Printf("serving git repositories from %s", s.Root)
+	if s.requireAuth() {
+		s.Info.Printf("requiring HTTP basic auth for user %q", s.Username)
+	}
+	if s.WatchInterval > 0 {
+		s.Info.Printf("watching for new repositories every %s", s.WatchInterval)
+		if err := s.refreshRepos(); err != nil {
+			return err
+		}
+		done := make(chan struct{})
+		defer close(done)
+		go s.watch(done)
+	}
 
 	if err := (&http.Server{Handler: s.handler()}).Serve(ln); err != nil {
 		return errors.Wrap(err, "serving")
@@ -83,7 +114,7 @@ func (s *Serve) handler() http.Handler {
 	})
 
 	mux.HandleFunc("/v1/list-repos", func(w http.ResponseWriter, r *http.Request) {
-		repos, err := s.Repos()
+		repos, err := s.servedRepos()
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -117,6 +148,11 @@ func (s *Serve) handler() http.Handler {
 		},
 	}
 	mux.Handle("/repos/", http.StripPrefix("/repos/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.WatchInterval > 0 && r.URL.Path != "" && !s.isServed(r.URL.Path) {
+			http.NotFound(w, r)
+			return
+		}
+
 		// Use git service if git is trying to clone. Otherwise show http.FileServer for convenience
 		for _, suffix := range []string{"/info/refs", "/git-upload-pack"} {
 			if strings.HasSuffix(r.URL.Path, suffix) {
@@ -128,10 +164,35 @@ func (s *Serve) handler() http.Handler {
 	})))
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.requireAuth() && !s.authorized(r) {
+			s.Debug.Printf("rejecting unauthenticated request for %s", r.URL.Path)
+			w.Header().Set("WWW-Authenticate", `Basic realm="src serve-git"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
 		mux.ServeHTTP(w, r)
 	})
 }
 
+// requireAuth returns true if credentials have been configured.
+func (s *Serve) requireAuth() bool {
+	return s.Username != "" || s.Password != ""
+}
+
+// authorized checks the basic auth credentials of r against the configured
+// credentials.
+func (s *Serve) authorized(r *http.Request) bool {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	// Evaluate both comparisons so the response time doesn't reveal which one
+	// failed.
+	usernameOK := subtle.ConstantTimeCompare([]byte(username), []byte(s.Username)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(s.Password)) == 1
+	return usernameOK && passwordOK
+}
+
 // Checks if git thinks the given path is a valid .git folder for a repository
 func isBareRepo(path string) bool {
 	c := exec.Command("git", "--git-dir", path, "rev-parse", "--is-bare-repository")
@@ -159,6 +220,94 @@ func isGitRepo(path string) bool {
 	return string(out) == ".git\n"
 }
 
+// hasCommit returns true if the repository at path has a commit checked out,
+// which is not the case while it is still being cloned.
+func hasCommit(path string) bool {
+	c := exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD^{commit}")
+	c.Dir = path
+	return c.Run() == nil
+}
+
+// watch re-scans Root for repositories every WatchInterval until done is closed.
+func (s *Serve) watch(done <-chan struct{}) {
+	ticker := time.NewTicker(s.WatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := s.refreshRepos(); err != nil {
+				s.Info.Printf("WARN: ignoring error re-scanning %s: %v", s.Root, err)
+			}
+		}
+	}
+}
+
+// refreshRepos scans Root for repositories and updates the served
+// repositories, logging any that were added or removed.
+func (s *Serve) refreshRepos() error {
+	found, err := s.Repos()
+	if err != nil {
+		return err
+	}
+
+	root, _ := filepath.EvalSymlinks(s.Root)
+	var repos []Repo
+	for _, repo := range found {
+		if !hasCommit(filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(repo.URI, "/repos")))) {
+			s.Debug.Printf("not serving repository without commits: %s", repo.Name)
+			continue
+		}
+		repos = append(repos, repo)
+	}
+
+	s.mu.Lock()
+	old := s.repos
+	s.repos = repos
+	s.mu.Unlock()
+
+	served := map[string]bool{}
+	for _, repo := range old {
+		served[repo.Name] = true
+	}
+	for _, repo := range repos {
+		if !served[repo.Name] {
+			s.Info.Printf("serving repository %s", repo.Name)
+		}
+		delete(served, repo.Name)
+	}
+	for name := range served {
+		s.Info.Printf("no longer serving repository %s", name)
+	}
+	return nil
+}
+
+// servedRepos returns the repositories to list. While watching, these are the
+// repositories found by the last scan, otherwise Root is scanned.
+func (s *Serve) servedRepos() ([]Repo, error) {
+	if s.WatchInterval <= 0 {
+		return s.Repos()
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Repo(nil), s.repos...), nil
+}
+
+// isServed returns true if the path, relative to /repos/, is within one of the
+// repositories found by the last scan.
+func (s *Serve) isServed(path string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, repo := range s.repos {
+		dir := strings.TrimPrefix(strings.TrimPrefix(repo.URI, "/repos"), "/")
+		if dir == "" || path == dir || strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 // Repos returns a slice of all the git repositories it finds.
 func (s *Serve) Repos() ([]Repo, error) {
 	var repos []Repo
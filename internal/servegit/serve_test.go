@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -61,6 +62,69 @@ func TestReposHandler(t *testing.T) {
 	}
 }
 
+func TestBasicAuth(t *testing.T) {
+	root := gitInitRepos(t, "project1")
+
+	h := (&Serve{
+		Info:     testLogger(t),
+		Debug:    discardLogger,
+		Addr:     testAddress,
+		Root:     root,
+		Username: "alice",
+		Password: "hunter2",
+	}).handler()
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	cases := []struct {
+		name               string
+		username, password string
+		noAuth             bool
+		wantStatus         int
+	}{{
+		name:       "no credentials",
+		noAuth:     true,
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:       "wrong password",
+		username:   "alice",
+		password:   "wrong",
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:       "wrong username",
+		username:   "bob",
+		password:   "hunter2",
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:       "valid credentials",
+		username:   "alice",
+		password:   "hunter2",
+		wantStatus: http.StatusOK,
+	}}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", ts.URL+"/v1/list-repos", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tc.noAuth {
+				req.SetBasicAuth(tc.username, tc.password)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != tc.wantStatus {
+				t.Errorf("unexpected status: want %d, got %d", tc.wantStatus, res.StatusCode)
+			}
+			if tc.wantStatus == http.StatusUnauthorized && res.Header.Get("WWW-Authenticate") == "" {
+				t.Error("expected WWW-Authenticate header on 401 response")
+			}
+		})
+	}
+}
+
 func testReposHandler(t *testing.T, h http.Handler, repos []Repo) {
 	ts := httptest.NewServer(h)
 	t.Cleanup(ts.Close)
@@ -204,3 +268,113 @@ func (tw testWriter) Write(p []byte) (n int, err error) {
 	tw.T.Log(string(p))
 	return len(p), nil
 }
+
+func TestWatch(t *testing.T) {
+	root := gitInitRepos(t, "project1")
+	gitCommit(t, filepath.Join(root, "project1"))
+
+	s := &Serve{
+		Info:          testLogger(t),
+		Debug:         discardLogger,
+		Addr:          testAddress,
+		Root:          root,
+		WatchInterval: time.Hour,
+	}
+	if err := s.refreshRepos(); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.handler())
+	t.Cleanup(ts.Close)
+
+	listRepos := func() []string {
+		res, err := http.Get(ts.URL + "/v1/list-repos")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		var resp struct{ Items []Repo }
+		if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, repo := range resp.Items {
+			names = append(names, repo.Name)
+		}
+		return names
+	}
+	status := func(path string) int {
+		res, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	// A repository without commits, e.g. one that is still being cloned, is
+	// neither listed nor served.
+	project2 := filepath.Join(root, "project2")
+	if err := os.MkdirAll(project2, 0755); err != nil {
+		t.Fatal(err)
+	}
+	gitInit(t, project2)
+	if err := s.refreshRepos(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"project1"}, listRepos()); diff != "" {
+		t.Errorf("unexpected repos (-want +got):\n%s", diff)
+	}
+	if got := status("/repos/project2/.git/info/refs?service=git-upload-pack"); got != http.StatusNotFound {
+		t.Errorf("unexpected status for repository without commits: %d", got)
+	}
+
+	// Once it has a commit, it is picked up by the next scan.
+	gitCommit(t, project2)
+	if err := s.refreshRepos(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"project1", "project2"}, listRepos()); diff != "" {
+		t.Errorf("unexpected repos (-want +got):\n%s", diff)
+	}
+
+	// Removed repositories are no longer listed.
+	if err := os.RemoveAll(filepath.Join(root, "project1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.refreshRepos(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"project2"}, listRepos()); diff != "" {
+		t.Errorf("unexpected repos (-want +got):\n%s", diff)
+	}
+}
+
+func gitCommit(t *testing.T, path string) {
+	cmd := exec.Command("git", "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "initial commit")
+	cmd.Dir = path
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %s\n%s", err, out)
+	}
+}
+
+func TestWatchStops(t *testing.T) {
+	s := &Serve{
+		Info:          testLogger(t),
+		Debug:         discardLogger,
+		Root:          t.TempDir(),
+		WatchInterval: time.Millisecond,
+	}
+
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		s.watch(done)
+		close(stopped)
+	}()
+	close(done)
+
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("watch did not stop")
+	}
+}